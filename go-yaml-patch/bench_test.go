@@ -0,0 +1,206 @@
+package yaml
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// benchmarkParse parses src to the end on each iteration
+func benchmarkParse(b *testing.B, src []byte, opts ...ParserOption) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(src)))
+	for i := 0; i < b.N; i++ {
+		p, err := NewParserFromBytes(src, opts...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for {
+			event, err := p.Next()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if event == nil {
+				break
+			}
+		}
+		p.Close()
+	}
+}
+
+// commentedConfig returns a configuration file with n entries, each with
+// head and line comments
+func commentedConfig(n int) []byte {
+	var b strings.Builder
+	b.WriteString("# Service configuration\n\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "# Settings of service %d\n", i)
+		fmt.Fprintf(&b, "service%d:  # named after its index\n", i)
+		b.WriteString("  port: 8080  # the default\n")
+		b.WriteString("  hosts: [a, b]  # both zones\n")
+	}
+	return []byte(b.String())
+}
+
+func BenchmarkParseSmall(b *testing.B) {
+	benchmarkParse(b, []byte("name: app\nport: 8080\ntags: [web, api]\n"))
+}
+
+func BenchmarkParseLargeSequence(b *testing.B) {
+	var src strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&src, "- item %d\n", i)
+	}
+	benchmarkParse(b, []byte(src.String()))
+}
+
+func BenchmarkParseDeepMapping(b *testing.B) {
+	var src strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&src, "%sk%d:\n", strings.Repeat("  ", i), i)
+	}
+	fmt.Fprintf(&src, "%sleaf: value\n", strings.Repeat("  ", 200))
+	benchmarkParse(b, []byte(src.String()))
+}
+
+func BenchmarkParseComments(b *testing.B) {
+	benchmarkParse(b, commentedConfig(500))
+}
+
+func BenchmarkEmit(b *testing.B) {
+	src := commentedConfig(500)
+	p, err := NewParserFromBytes(src)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var events []*Event
+	for {
+		event, err := p.Next()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		events = append(events, event)
+	}
+	p.Close()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e, err := NewEmitterWithOptions(io.Discard, EmitterOptions{Preserve: true})
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, event := range events {
+			if err := e.Emit(event); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := e.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSkipComments(b *testing.B) {
+	src := commentedConfig(500)
+	b.Run("comments", func(b *testing.B) {
+		benchmarkParse(b, src)
+	})
+	b.Run("skip", func(b *testing.B) {
+		benchmarkParse(b, src, WithSkipComments())
+	})
+}
+
+// records returns a sequence of n small mappings
+func records(n int) []byte {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "- id: %d\n  name: item %d\n  tags: [a, b]\n", i, i)
+	}
+	return []byte(b.String())
+}
+
+// benchmarkNextInto parses src to the end with NextInto on each iteration,
+// reusing one Event
+func benchmarkNextInto(b *testing.B, src []byte) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(src)))
+	var event Event
+	for i := 0; i < b.N; i++ {
+		p, err := NewParserFromBytes(src)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for {
+			ok, err := p.NextInto(&event)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if !ok {
+				break
+			}
+		}
+		p.Close()
+	}
+}
+
+func BenchmarkNext(b *testing.B) {
+	benchmarkParse(b, records(2000))
+}
+
+func BenchmarkNextInto(b *testing.B) {
+	benchmarkNextInto(b, records(2000))
+}
+
+// benchmarkScalars parses src to the end on each iteration, passing the
+// value of every scalar to use
+func benchmarkScalars(b *testing.B, src []byte, use func(*Event) int) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(src)))
+	total := 0
+	for i := 0; i < b.N; i++ {
+		p, err := NewParserFromBytes(src)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for {
+			event, err := p.Next()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if event == nil {
+				break
+			}
+			if event.Type == EventScalar {
+				total += use(event)
+			}
+		}
+		p.Close()
+	}
+	if total < 0 {
+		b.Fatal("negative length")
+	}
+}
+
+func BenchmarkValue(b *testing.B) {
+	benchmarkScalars(b, records(2000), func(e *Event) int { return len([]byte(e.Value)) })
+}
+
+func BenchmarkValueBytes(b *testing.B) {
+	benchmarkScalars(b, records(2000), func(e *Event) int { return len(e.ValueBytes()) })
+}
+
+func BenchmarkNextIntoComments(b *testing.B) {
+	src := commentedConfig(500)
+	b.Run("next", func(b *testing.B) {
+		benchmarkParse(b, src)
+	})
+	b.Run("next into", func(b *testing.B) {
+		benchmarkNextInto(b, src)
+	})
+}
@@ -0,0 +1,33 @@
+package yaml
+
+import "strings"
+
+// HeadCommentText returns the head comment with comment markers stripped
+func (e *Event) HeadCommentText() string { return commentText(e.HeadComment) }
+
+// LineCommentText returns the line comment with comment markers stripped
+func (e *Event) LineCommentText() string { return commentText(e.LineComment) }
+
+// FootCommentText returns the foot comment with comment markers stripped
+func (e *Event) FootCommentText() string { return commentText(e.FootComment) }
+
+// TailCommentText returns the tail comment with comment markers stripped
+func (e *Event) TailCommentText() string { return commentText(e.TailComment) }
+
+// commentText strips the leading "#" and the single space after it from
+// each line of a raw comment. Lines are joined with "\n", and blank lines
+// within the comment are kept as empty lines.
+func commentText(comment []byte) string {
+	if len(comment) == 0 {
+		return ""
+	}
+	lines := strings.Split(string(comment), "\n")
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(line, "#") {
+			line = strings.TrimPrefix(line[1:], " ")
+		}
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,145 @@
+package yaml
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// commentLines returns the comment lines carried by the given events,
+// trimmed and sorted
+func commentLines(events []*Event) []string {
+	var lines []string
+	for _, event := range events {
+		for _, comment := range [][]byte{event.HeadComment, event.LineComment, event.FootComment, event.TailComment} {
+			for _, line := range strings.Split(string(comment), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, line)
+				}
+			}
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// sourceCommentPattern matches a comment in a test document, which never
+// has a "#" inside a scalar
+var sourceCommentPattern = regexp.MustCompile(`#.*`)
+
+// sourceComments returns the comment lines of a test document, trimmed and
+// sorted
+func sourceComments(src string) []string {
+	lines := sourceCommentPattern.FindAllString(src, -1)
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func TestCommentPlacement(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		event func([]*Event) *Event
+		field func(*Event) []byte
+		want  string
+	}{
+		{
+			"document head", "# top\n\nkey: value\n",
+			firstOfType(EventDocumentStart), headField, "# top",
+		},
+		{
+			"key head", "# about key\nkey: value\n",
+			scalarNamed("key"), headField, "# about key",
+		},
+		{
+			"second key head", "a: 1\n# about b\nb: 2\n",
+			scalarNamed("b"), headField, "# about b",
+		},
+		{
+			"item line", "- a # one\n- b\n",
+			scalarNamed("a"), lineField, "# one",
+		},
+		{
+			"between items", "- a\n# before b\n- b\n",
+			scalarNamed("b"), headField, "# before b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := tt.event(parseEvents(t, tt.src))
+			if event == nil {
+				t.Fatal("event not found")
+			}
+			if got := string(tt.field(event)); got != tt.want {
+				t.Errorf("comment on %v is %q, want %q", event.Type, got, tt.want)
+			}
+		})
+	}
+}
+
+func firstOfType(typ EventType) func([]*Event) *Event {
+	return func(events []*Event) *Event {
+		for _, event := range events {
+			if event.Type == typ {
+				return event
+			}
+		}
+		return nil
+	}
+}
+
+func scalarNamed(value string) func([]*Event) *Event {
+	return func(events []*Event) *Event {
+		for _, event := range events {
+			if event.Type == EventScalar && event.Value == value {
+				return event
+			}
+		}
+		return nil
+	}
+}
+
+func headField(e *Event) []byte { return e.HeadComment }
+func lineField(e *Event) []byte { return e.LineComment }
+
+// commentedDocs hold comments in the places that are easy to lose: at
+// document boundaries, between sequence items, after the final key and
+// at the end of nested values
+var commentedDocs = []struct {
+	name string
+	src  string
+}{
+	{"document boundaries", "# stream head\n\na: 1\n# document foot\n...\n--- b\n"},
+	{"sequence items", "# head\n- a  # line a\n# between\n- b\n# after items\n"},
+	{"final key", "a: 1\nb: 2\n# after the final key\n"},
+	{"final nested value", "outer:\n  inner: 1\n  # foot of inner\n# foot of outer\n"},
+	{"dedent between keys", "a:\n  b: 1\n  # foot of b\nc: 2\n"},
+	{"nested sequence", "list:\n- x\n- y\n# after the list\nnext: z\n"},
+	{"flow line comment", "list: [a, b]  # flow\nmap: {k: v}  # flow map\n"},
+}
+
+// TestCommentsKept checks that the parser attaches every comment of a
+// document to some event
+func TestCommentsKept(t *testing.T) {
+	for _, doc := range commentedDocs {
+		t.Run(doc.name, func(t *testing.T) {
+			got := commentLines(parseEvents(t, doc.src))
+			want := sourceComments(doc.src)
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("events carry comments %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSkipComments(t *testing.T) {
+	for _, doc := range commentedDocs {
+		if got := commentLines(parseEvents(t, doc.src, WithSkipComments())); len(got) != 0 {
+			t.Errorf("%s: events carry comments %q with SkipComments", doc.name, got)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package yaml
+
+// NewStreamStart returns a STREAM-START event for emitting
+func NewStreamStart() *Event {
+	return &Event{Type: EventStreamStart}
+}
+
+// NewStreamEnd returns a STREAM-END event for emitting
+func NewStreamEnd() *Event {
+	return &Event{Type: EventStreamEnd}
+}
+
+// NewDocumentStart returns a DOCUMENT-START event for emitting. An
+// implicit start leaves out the "---" marker where the emitter can.
+func NewDocumentStart(implicit bool) *Event {
+	return &Event{Type: EventDocumentStart, Implicit: implicit, VersionMajor: 1, VersionMinor: 1}
+}
+
+// NewDocumentEnd returns a DOCUMENT-END event for emitting. An implicit end
+// leaves out the "..." marker.
+func NewDocumentEnd(implicit bool) *Event {
+	return &Event{Type: EventDocumentEnd, Implicit: implicit}
+}
+
+// NewScalar returns an untagged SCALAR event with the given value and
+// style. With the zero style the emitter writes the value plain when it
+// can and quoted otherwise. The implicit flags are set as the parser sets
+// them for an untagged scalar of that style.
+func NewScalar(value string, style EventStyle) *Event {
+	event := &Event{Type: EventScalar, Value: value, Style: style}
+	event.Implicit = event.IsPlain()
+	event.PlainImplicit = event.Implicit
+	event.QuotedImplicit = !event.Implicit
+	return event
+}
+
+// NewAlias returns an ALIAS event referring to the given anchor
+func NewAlias(anchor string) *Event {
+	return &Event{Type: EventAlias, Anchor: anchor}
+}
+
+// NewSequenceStart returns an untagged SEQUENCE-START event with the given
+// style. With the zero style the emitter chooses between block and flow.
+func NewSequenceStart(style EventStyle) *Event {
+	return &Event{Type: EventSequenceStart, Style: style, Implicit: true}
+}
+
+// NewSequenceEnd returns a SEQUENCE-END event
+func NewSequenceEnd() *Event {
+	return &Event{Type: EventSequenceEnd}
+}
+
+// NewMappingStart returns an untagged MAPPING-START event with the given
+// style. With the zero style the emitter chooses between block and flow.
+func NewMappingStart(style EventStyle) *Event {
+	return &Event{Type: EventMappingStart, Style: style, Implicit: true}
+}
+
+// NewMappingEnd returns a MAPPING-END event
+func NewMappingEnd() *Event {
+	return &Event{Type: EventMappingEnd}
+}
+
+// The helpers below build the same events as the constructors above with
+// the zero style, and implicit document markers. They are kept for callers
+// written before the constructors took a style.
+
+// StreamStartEvent returns a STREAM-START event, as NewStreamStart does
+func StreamStartEvent() *Event { return NewStreamStart() }
+
+// StreamEndEvent returns a STREAM-END event, as NewStreamEnd does
+func StreamEndEvent() *Event { return NewStreamEnd() }
+
+// DocumentStartEvent returns an implicit DOCUMENT-START event
+func DocumentStartEvent() *Event { return NewDocumentStart(true) }
+
+// DocumentEndEvent returns an implicit DOCUMENT-END event
+func DocumentEndEvent() *Event { return NewDocumentEnd(true) }
+
+// ScalarEvent returns an untagged SCALAR event with the given value, which
+// the emitter writes plain when it can and quoted otherwise
+func ScalarEvent(value string) *Event { return NewScalar(value, 0) }
+
+// AliasEvent returns an ALIAS event, as NewAlias does
+func AliasEvent(anchor string) *Event { return NewAlias(anchor) }
+
+// SequenceStartEvent returns an untagged SEQUENCE-START event whose style
+// the emitter chooses
+func SequenceStartEvent() *Event { return NewSequenceStart(0) }
+
+// SequenceEndEvent returns a SEQUENCE-END event, as NewSequenceEnd does
+func SequenceEndEvent() *Event { return NewSequenceEnd() }
+
+// MappingStartEvent returns an untagged MAPPING-START event whose style the
+// emitter chooses
+func MappingStartEvent() *Event { return NewMappingStart(0) }
+
+// MappingEndEvent returns a MAPPING-END event, as NewMappingEnd does
+func MappingEndEvent() *Event { return NewMappingEnd() }
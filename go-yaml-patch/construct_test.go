@@ -0,0 +1,32 @@
+package yaml
+
+import "testing"
+
+func TestConstructors(t *testing.T) {
+	want := parseEvents(t, "a: [1, two]\nb: {c: '3'}\n")
+	streams := map[string][]*Event{
+		"constructors": {
+			NewStreamStart(), NewDocumentStart(true), NewMappingStart(0),
+			NewScalar("a", 0), NewSequenceStart(StyleFlow),
+			NewScalar("1", 0), NewScalar("two", 0), NewSequenceEnd(),
+			NewScalar("b", 0), NewMappingStart(0),
+			NewScalar("c", 0), NewScalar("3", StyleSingleQuoted), NewMappingEnd(),
+			NewMappingEnd(), NewDocumentEnd(true), NewStreamEnd(),
+		},
+		"helpers": {
+			StreamStartEvent(), DocumentStartEvent(), MappingStartEvent(),
+			ScalarEvent("a"), SequenceStartEvent(),
+			ScalarEvent("1"), ScalarEvent("two"), SequenceEndEvent(),
+			ScalarEvent("b"), MappingStartEvent(),
+			ScalarEvent("c"), NewScalar("3", StyleSingleQuoted), MappingEndEvent(),
+			MappingEndEvent(), DocumentEndEvent(), StreamEndEvent(),
+		},
+	}
+	for name, events := range streams {
+		out := emitEvents(t, events, EmitterOptions{Preserve: true})
+		ops, err := Diff(want, parseEvents(t, out))
+		if err != nil || len(ops) != 0 {
+			t.Errorf("%s: emitted %q, which differs: %v %v", name, out, ops, err)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// DiffOpType is the kind of change a DiffOp records
+type DiffOpType int
+
+const (
+	DiffAdd DiffOpType = iota + 1
+	DiffRemove
+	DiffReplace
+)
+
+func (t DiffOpType) String() string {
+	switch t {
+	case DiffAdd:
+		return "add"
+	case DiffRemove:
+		return "remove"
+	case DiffReplace:
+		return "replace"
+	default:
+		return "unknown"
+	}
+}
+
+// DiffOp is one change between two documents. Path is the JSON Pointer
+// (RFC 6901) of the node that changed, and Old and New hold the events of
+// the node before and after, nil for an added or removed node.
+type DiffOp struct {
+	Op   DiffOpType
+	Path string
+	Old  []*Event
+	New  []*Event
+}
+
+// DiffOptions controls what Diff treats as a change
+type DiffOptions struct {
+	// Comments reports nodes and keys whose comments differ
+	Comments bool
+	// Styles reports nodes whose scalar or collection style differs
+	Styles bool
+	// MaxExpansions is the number of nodes that may be compared through
+	// aliases in each document before an ExpansionLimitError is returned,
+	// as for ExpandOptions. Zero selects DefaultMaxExpansions.
+	MaxExpansions int
+}
+
+// Diff compares the first document in a with the first document in b
+func Diff(a, b []*Event) ([]DiffOp, error) {
+	return DiffWithOptions(a, b, DiffOptions{})
+}
+
+// DiffWithOptions compares the first document in a with the first document
+// in b using the given options, and returns the changes that turn a into
+// b. The comparison is structural: mapping entries are matched by scalar
+// key regardless of their order, sequence items by index, and scalars by
+// value and resolved tag, so formatting, comments and styles make no
+// difference unless the options ask for them. Aliases are compared as the
+// nodes they refer to, and an alias to a collection that encloses it is a
+// CycleError. The nodes compared through aliases are capped by
+// DiffOptions.MaxExpansions. Entries with collection keys cannot be
+// matched and are reported as removed and added.
+//
+// Changes are listed in document order. Removed sequence items are listed
+// from the last, so the changes can be applied one after another.
+func DiffWithOptions(a, b []*Event, options DiffOptions) ([]DiffOp, error) {
+	d := differ{a: newDiffDoc(a), b: newDiffDoc(b), options: options, limit: options.MaxExpansions}
+	if d.limit == 0 {
+		d.limit = DefaultMaxExpansions
+	}
+	i, _, err := rootNode(a)
+	if err != nil {
+		return nil, err
+	}
+	j, _, err := rootNode(b)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case i < 0 && j < 0:
+		return nil, nil
+	case i < 0:
+		return []DiffOp{{Op: DiffAdd, New: d.b.node(j)}}, nil
+	case j < 0:
+		return []DiffOp{{Op: DiffRemove, Old: d.a.node(i)}}, nil
+	}
+	if err := d.compare("", i, j); err != nil {
+		return nil, err
+	}
+	return d.ops, nil
+}
+
+// diffDoc is one side of a diff
+type diffDoc struct {
+	events []*Event
+	// targets maps the index of each alias to the index of the node it
+	// refers to
+	targets map[int]int
+	// open holds the start indexes of the collections being compared
+	open []int
+	// expansion is the outermost alias whose node is being compared, and
+	// expansions counts the nodes compared through aliases
+	expansion  *Event
+	expansions int
+}
+
+func newDiffDoc(events []*Event) diffDoc {
+	d := diffDoc{events: events, targets: make(map[int]int)}
+	anchors := make(map[string]int)
+	for i, event := range events {
+		switch {
+		case event.Type == EventDocumentStart:
+			anchors = make(map[string]int)
+		case event.Type == EventAlias:
+			if target, ok := anchors[event.Anchor]; ok {
+				d.targets[i] = target
+			}
+		case event.Anchor != "":
+			anchors[event.Anchor] = i
+		}
+	}
+	return d
+}
+
+// resolve returns the index of the node the node at events[i] stands for,
+// following an alias
+func (d *diffDoc) resolve(i int) (int, error) {
+	alias := d.events[i]
+	if alias.Type != EventAlias {
+		return i, nil
+	}
+	target, ok := d.targets[i]
+	if !ok {
+		return i, fmt.Errorf("%s: unknown anchor %q referenced",
+			position(alias.StartMark), alias.Anchor)
+	}
+	for _, open := range d.open {
+		if open == target {
+			return i, &CycleError{
+				Anchor:     alias.Anchor,
+				Path:       alias.Path,
+				AnchorMark: d.events[target].StartMark,
+				Mark:       alias.StartMark,
+			}
+		}
+	}
+	return target, nil
+}
+
+// count records a node compared at the given index, which was resolved
+// from the node at alias, and returns an error once the nodes compared
+// through aliases pass the limit. It returns a function that ends the
+// expansion started by an outermost alias.
+func (d *diffDoc) count(alias, i, limit int) (func(), error) {
+	done := func() {}
+	if i != alias && d.expansion == nil {
+		d.expansion = d.events[alias]
+		done = func() { d.expansion = nil }
+	}
+	if d.expansion == nil {
+		return done, nil
+	}
+	if d.expansions++; d.expansions > limit {
+		done()
+		return nil, &ExpansionLimitError{
+			Limit:  limit,
+			Anchor: d.expansion.Anchor,
+			Path:   d.expansion.Path,
+			Mark:   d.expansion.StartMark,
+		}
+	}
+	return done, nil
+}
+
+// node returns the events of the node starting at events[i]
+func (d *diffDoc) node(i int) []*Event {
+	end, _ := skipNode(d.events, i)
+	return d.events[i:end]
+}
+
+// differ collects the changes between two documents
+type differ struct {
+	a, b    diffDoc
+	options DiffOptions
+	limit   int
+	ops     []DiffOp
+}
+
+func (d *differ) add(op DiffOpType, path string, i, j int) {
+	diffOp := DiffOp{Op: op, Path: path}
+	if op != DiffAdd {
+		diffOp.Old = d.a.node(i)
+	}
+	if op != DiffRemove {
+		diffOp.New = d.b.node(j)
+	}
+	d.ops = append(d.ops, diffOp)
+}
+
+// compare records the changes between the node at a.events[i] and the
+// node at b.events[j], both at the given path
+func (d *differ) compare(path string, i, j int) error {
+	ri, err := d.a.resolve(i)
+	if err != nil {
+		return err
+	}
+	rj, err := d.b.resolve(j)
+	if err != nil {
+		return err
+	}
+	doneA, err := d.a.count(i, ri, d.limit)
+	if err != nil {
+		return err
+	}
+	defer doneA()
+	doneB, err := d.b.count(j, rj, d.limit)
+	if err != nil {
+		return err
+	}
+	defer doneB()
+	i, j = ri, rj
+
+	ea, eb := d.a.events[i], d.b.events[j]
+	switch {
+	case ea.Type != eb.Type || !d.sameEvent(ea, eb):
+		d.add(DiffReplace, path, i, j)
+		return nil
+	case ea.Type == EventScalar:
+		return nil
+	}
+
+	d.a.open = append(d.a.open, i)
+	d.b.open = append(d.b.open, j)
+	if ea.Type == EventSequenceStart {
+		err = d.compareSequences(path, i, j)
+	} else {
+		err = d.compareMappings(path, i, j)
+	}
+	d.a.open = d.a.open[:len(d.a.open)-1]
+	d.b.open = d.b.open[:len(d.b.open)-1]
+	return err
+}
+
+// sameEvent reports whether two events of the same type are equal, apart
+// from what their children hold
+func (d *differ) sameEvent(a, b *Event) bool {
+	if a.Type == EventScalar && (a.Value != b.Value || a.ResolvedTag() != b.ResolvedTag()) {
+		return false
+	}
+	if a.Type.IsCollectionStart() && a.ResolvedTag() != b.ResolvedTag() {
+		return false
+	}
+	if d.options.Styles && a.Style != b.Style {
+		return false
+	}
+	return !d.options.Comments || sameComments(a, b)
+}
+
+// sameComments reports whether two events carry the same comments
+func sameComments(a, b *Event) bool {
+	return bytes.Equal(a.HeadComment, b.HeadComment) &&
+		bytes.Equal(a.LineComment, b.LineComment) &&
+		bytes.Equal(a.FootComment, b.FootComment)
+}
+
+func (d *differ) compareSequences(path string, i, j int) error {
+	itemsA, itemsB := d.a.items(i), d.b.items(j)
+	for k := 0; k < len(itemsA) && k < len(itemsB); k++ {
+		if err := d.compare(path+"/"+strconv.Itoa(k), itemsA[k], itemsB[k]); err != nil {
+			return err
+		}
+	}
+	for k := len(itemsA) - 1; k >= len(itemsB); k-- {
+		d.add(DiffRemove, path+"/"+strconv.Itoa(k), itemsA[k], 0)
+	}
+	for k := len(itemsA); k < len(itemsB); k++ {
+		d.add(DiffAdd, path+"/"+strconv.Itoa(k), 0, itemsB[k])
+	}
+	return nil
+}
+
+func (d *differ) compareMappings(path string, i, j int) error {
+	keysA, err := d.a.entries(i)
+	if err != nil {
+		return err
+	}
+	keysB, err := d.b.entries(j)
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string]diffEntry, len(keysB))
+	for _, entry := range keysB {
+		if entry.scalar {
+			byKey[entry.key] = entry
+		}
+	}
+
+	matched := make(map[string]bool)
+	for _, entry := range keysA {
+		match, ok := byKey[entry.key]
+		entryPath := path + "/" + pathEscaper.Replace(entry.key)
+		if !entry.scalar || !ok {
+			d.add(DiffRemove, entryPath, entry.value, 0)
+			continue
+		}
+		matched[entry.key] = true
+		if d.options.Comments && !sameComments(d.a.events[entry.keyIndex], d.b.events[match.keyIndex]) {
+			d.add(DiffReplace, entryPath, entry.value, match.value)
+			continue
+		}
+		if err := d.compare(entryPath, entry.value, match.value); err != nil {
+			return err
+		}
+	}
+	for _, entry := range keysB {
+		if entry.scalar && matched[entry.key] {
+			continue
+		}
+		d.add(DiffAdd, path+"/"+pathEscaper.Replace(entry.key), 0, entry.value)
+	}
+	return nil
+}
+
+// items returns the start indexes of the items of the sequence starting at
+// events[i]
+func (d *diffDoc) items(i int) []int {
+	var items []int
+	for k := i + 1; k < len(d.events) && !d.events[k].Type.IsCollectionEnd(); {
+		items = append(items, k)
+		end, err := skipNode(d.events, k)
+		if err != nil {
+			break
+		}
+		k = end
+	}
+	return items
+}
+
+// diffEntry is a mapping entry being compared
+type diffEntry struct {
+	key      string // the key's value, or its path segment if not a scalar
+	scalar   bool
+	keyIndex int // index of the key's event, after resolving an alias
+	value    int // index of the value's first event
+}
+
+// entries returns the entries of the mapping starting at events[i]
+func (d *diffDoc) entries(i int) ([]diffEntry, error) {
+	node := d.node(i)
+	bounds, err := mappingEntries(node)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]diffEntry, len(bounds))
+	for k, bound := range bounds {
+		key, err := d.resolve(i + bound.key)
+		if err != nil {
+			return nil, err
+		}
+		event := d.events[key]
+		entries[k] = diffEntry{
+			key:      keySegment(event),
+			scalar:   event.Type == EventScalar,
+			keyIndex: key,
+			value:    i + bound.value,
+		}
+		if entries[k].scalar {
+			entries[k].key = event.Value
+		}
+	}
+	return entries, nil
+}
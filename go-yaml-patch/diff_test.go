@@ -0,0 +1,75 @@
+package yaml
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []DiffOp // Op and Path only
+	}{
+		{"equal", "a: 1\nb: [x, y]\n", "a: 1\nb: [x, y]\n", nil},
+		{"key order", "a: 1\nb: 2\n", "b: 2\na: 1\n", nil},
+		{"styles", "a: 'x'\nb: [1]\n", "a: x\nb:\n- 1\n", nil},
+		{"add key", "a: 1\n", "a: 1\nb: 2\n", []DiffOp{{Op: DiffAdd, Path: "/b"}}},
+		{"remove key", "a: 1\nb: 2\n", "a: 1\n", []DiffOp{{Op: DiffRemove, Path: "/b"}}},
+		{"replace scalar", "a: 1\n", "a: 2\n", []DiffOp{{Op: DiffReplace, Path: "/a"}}},
+		{"replace tag", "a: 1\n", "a: '1'\n", []DiffOp{{Op: DiffReplace, Path: "/a"}}},
+		{"replace kind", "a: [1]\n", "a: {b: 1}\n", []DiffOp{{Op: DiffReplace, Path: "/a"}}},
+		{
+			"sequence items", "- a\n- b\n- c\n", "- a\n", []DiffOp{
+				{Op: DiffRemove, Path: "/2"}, {Op: DiffRemove, Path: "/1"},
+			},
+		},
+		{"add item", "- a\n", "- a\n- b\n", []DiffOp{{Op: DiffAdd, Path: "/1"}}},
+		{"escaped key", "a/b: 1\n", "a/b: 2\n", []DiffOp{{Op: DiffReplace, Path: "/a~1b"}}},
+		{"alias and copy", "a: &x {k: [1, 2]}\nb: *x\n", "a: {k: [1, 2]}\nb: {k: [1, 2]}\n", nil},
+		{"alias changed", "a: &x {k: 1}\nb: *x\n", "a: {k: 1}\nb: {k: 2}\n", []DiffOp{{Op: DiffReplace, Path: "/b/k"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := Diff(parseEvents(t, tt.a), parseEvents(t, tt.b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ops) != len(tt.want) {
+				t.Fatalf("got %d changes %v, want %d", len(ops), ops, len(tt.want))
+			}
+			for k, op := range ops {
+				if op.Op != tt.want[k].Op || op.Path != tt.want[k].Path {
+					t.Errorf("change %d is %v at %q, want %v at %q", k, op.Op, op.Path, tt.want[k].Op, tt.want[k].Path)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffOptions(t *testing.T) {
+	a, b := parseEvents(t, "a: 'x'  # one\n"), parseEvents(t, "a: x  # two\n")
+	for _, options := range []DiffOptions{{Styles: true}, {Comments: true}} {
+		ops, err := DiffWithOptions(a, b, options)
+		if err != nil || len(ops) != 1 || ops[0].Path != "/a" {
+			t.Errorf("with %+v got changes %v and error %v, want one at /a", options, ops, err)
+		}
+	}
+}
+
+func TestDiffAliases(t *testing.T) {
+	cycle := parseEvents(t, "a: &x\n  b: *x\n")
+	_, err := Diff(cycle, cycle)
+	if _, ok := err.(*CycleError); !ok {
+		t.Errorf("got error %v for a cycle, want a CycleError", err)
+	}
+
+	lol := parseEvents(t, laughs(8))
+	_, err = DiffWithOptions(lol, lol, DiffOptions{MaxExpansions: 1000})
+	if e, ok := err.(*ExpansionLimitError); !ok || e.Limit != 1000 {
+		t.Errorf("got error %v for billion laughs, want an ExpansionLimitError", err)
+	}
+	if _, err := Diff(lol, lol); err == nil {
+		t.Error("billion laughs is within the default limit")
+	}
+	if ops, err := Diff(parseEvents(t, laughs(2)), parseEvents(t, laughs(2))); err != nil || len(ops) != 0 {
+		t.Errorf("two levels gave changes %v and error %v", ops, err)
+	}
+}
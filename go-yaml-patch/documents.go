@@ -0,0 +1,304 @@
+package yaml
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// NextDocument consumes the next document in the stream and returns its
+// events, from DOCUMENT-START through the matching DOCUMENT-END inclusive.
+// STREAM-START and STREAM-END are skipped, and nil is returned once the
+// stream has no more documents.
+func (p *Parser) NextDocument() ([]*Event, error) {
+	var events []*Event
+	for {
+		event, err := p.Next()
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			return nil, nil
+		}
+		switch event.Type {
+		case EventStreamStart, EventStreamEnd:
+			continue
+		}
+		events = append(events, event)
+		if event.Type == EventDocumentEnd {
+			return events, nil
+		}
+	}
+}
+
+// FirstDocument parses the first document of the given YAML stream and
+// returns its events, as NextDocument does, or nil if the stream has no
+// document. Whatever follows the document, such as the text after YAML
+// front matter, is neither parsed nor an error: the input is read line by
+// line, and the parser is given nothing past the "---" or "..." line that
+// ends the first document, since it scans a few tokens ahead of the events
+// it returns. The document ends there or at the end of the input. Input
+// in UTF-16, detected by its byte order mark or forced with the Encoding
+// option, is passed to the parser whole.
+func FirstDocument(reader io.Reader, opts ...ParserOption) ([]*Event, error) {
+	options := parserOptions(opts)
+	if options.Encoding == EncodingAuto || options.Encoding == EncodingUTF8 {
+		reader = &firstDocumentReader{reader: bufio.NewReader(reader), start: true}
+	}
+	parser, err := NewParserWithOptions(reader, options)
+	if err != nil {
+		return nil, err
+	}
+	defer parser.Close()
+	return parser.NextDocument()
+}
+
+// firstDocumentReader passes on the lines of its reader up to the end of
+// the first document
+type firstDocumentReader struct {
+	reader  *bufio.Reader
+	pending []byte // bytes of the current line not yet returned
+	err     error  // error to return once pending is empty
+	start   bool   // whether the next line is the first of the input
+	midLine bool   // whether the next bytes continue a long line
+	utf16   bool   // whether the input is UTF-16, which is passed on whole
+	started bool   // whether the first document has started
+	done    bool
+}
+
+func (f *firstDocumentReader) Read(p []byte) (int, error) {
+	for len(f.pending) == 0 {
+		if f.done || f.err != nil {
+			if f.err == nil {
+				return 0, io.EOF
+			}
+			return 0, f.err
+		}
+		line, err := f.reader.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull {
+			f.err = err
+		}
+		if len(line) == 0 {
+			continue
+		}
+		if !f.midLine && !f.utf16 && !f.firstDocumentLine(line) {
+			f.done = true
+			continue
+		}
+		f.pending = line
+		f.midLine = line[len(line)-1] != '\n'
+	}
+	n := copy(p, f.pending)
+	f.pending = f.pending[n:]
+	return n, nil
+}
+
+// firstDocumentLine records the given line, and reports whether it is part
+// of the first document or of what comes before it
+func (f *firstDocumentReader) firstDocumentLine(line []byte) bool {
+	if f.start {
+		f.start = false
+		if bytes.HasPrefix(line, []byte{0xff, 0xfe}) || bytes.HasPrefix(line, []byte{0xfe, 0xff}) {
+			f.utf16 = true
+			return true
+		}
+		line = bytes.TrimPrefix(line, utf8BOM)
+	}
+	switch {
+	case isMarker(line, "---"):
+		if f.started {
+			return false
+		}
+		f.started = true
+	case isMarker(line, "..."):
+		if f.started {
+			f.done = true
+		}
+	case !f.started:
+		trimmed := bytes.TrimLeft(line, " \t\r\n")
+		f.started = len(trimmed) > 0 && trimmed[0] != '#' && trimmed[0] != '%'
+	}
+	return true
+}
+
+// isMarker reports whether the given line starts with the given document
+// marker
+func isMarker(line []byte, marker string) bool {
+	if !bytes.HasPrefix(line, []byte(marker)) {
+		return false
+	}
+	return len(line) == len(marker) || bytes.IndexByte([]byte(" \t\r\n"), line[len(marker)]) >= 0
+}
+
+// CountDocuments reads a YAML stream and returns the number of documents it
+// contains. It runs the underlying parser directly, so no Events are built.
+func CountDocuments(reader io.Reader) (int, error) {
+	var parser yaml_parser_t
+	if !yaml_parser_initialize(&parser) {
+		return 0, fmt.Errorf("failed to initialize YAML parser")
+	}
+	defer yaml_parser_delete(&parser)
+	yaml_parser_set_input_reader(&parser, reader)
+
+	count := 0
+	err := runParser(&parser, func(typ yaml_event_type_t) {
+		if typ == yaml_DOCUMENT_START_EVENT {
+			count++
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ParseScalar reads a YAML stream holding a single document whose content
+// is a lone scalar, such as a version file, and returns the scalar's value
+// and the tag it resolves to under the core schema. An empty document
+// holds the implicit null scalar and returns an empty value with the null
+// tag. Any other content, or a stream with no document or several, is an
+// error.
+func ParseScalar(reader io.Reader) (value string, tag string, err error) {
+	parser, err := NewParser(reader)
+	if err != nil {
+		return "", "", err
+	}
+	defer parser.Close()
+
+	var scalar *Event
+	expect := []EventType{EventStreamStart, EventDocumentStart, EventScalar, EventDocumentEnd, EventStreamEnd}
+	for _, typ := range expect {
+		event, err := parser.Next()
+		if err != nil {
+			return "", "", err
+		}
+		if event == nil {
+			return "", "", fmt.Errorf("expected a single scalar document, found the end of the stream")
+		}
+		if event.Type != typ {
+			return "", "", fmt.Errorf("%s: expected a single scalar document, found %v",
+				position(event.StartMark), event.Type)
+		}
+		if typ == EventScalar {
+			scalar = event
+		}
+	}
+	return scalar.Value, scalar.ResolvedTag(), nil
+}
+
+// SplitOptions controls how SplitDocuments slices a YAML stream
+type SplitOptions struct {
+	// IncludeMarkers keeps the directives and "---" marker that start a
+	// document and the "..." marker that ends it in the document's bytes.
+	// Without it, an explicit document starts just after its "---" and
+	// ends just before its "...", so its directives are dropped.
+	IncludeMarkers bool
+}
+
+// SplitDocuments returns the source bytes of each document in the given
+// YAML stream, without the "---" and "..." markers
+func SplitDocuments(b []byte) ([][]byte, error) {
+	return SplitDocumentsWithOptions(b, SplitOptions{})
+}
+
+// SplitDocumentsWithOptions returns the source bytes of each document in
+// the given YAML stream using the given options. Documents are sliced at
+// the marks of their DOCUMENT-START and DOCUMENT-END events, and a
+// document without a "---" marker starts where the previous one ended, so
+// comments between documents stay with the document that follows them.
+// The returned slices share the memory of b.
+func SplitDocumentsWithOptions(b []byte, options SplitOptions) ([][]byte, error) {
+	parser, err := NewParserFromBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	defer parser.Close()
+
+	var docs [][]byte
+	var lo, start int
+	for {
+		event, err := parser.Next()
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			return docs, nil
+		}
+		switch event.Type {
+		case EventStreamStart:
+			lo = parser.bomLength()
+		case EventDocumentStart:
+			switch {
+			case event.Implicit:
+				start = lo
+			case options.IncludeMarkers:
+				start = event.StartMark.ByteOffset
+			default:
+				start = event.EndMark.ByteOffset
+			}
+		case EventDocumentEnd:
+			end := event.StartMark.ByteOffset
+			if options.IncludeMarkers {
+				end = event.EndMark.ByteOffset
+			}
+			if start > end {
+				start = end
+			}
+			docs = append(docs, b[start:end:end])
+			lo = event.EndMark.ByteOffset
+		}
+	}
+}
+
+// ExtractFrontMatter splits the YAML front matter from the start of b, as
+// found at the top of Markdown files: a document opened by a "---" line and
+// closed by the next "---" or "..." line. It returns the events of the
+// document and the bytes after the closing line, which are not parsed and
+// share the memory of b. If b does not start with a "---" line it has no
+// front matter, and ExtractFrontMatter returns no events and all of b.
+//
+// The closing line is found in the bytes before anything is parsed, and
+// only the front matter up to it is given to the parser, so the text after
+// it is never scanned. Front matter is read as UTF-8, after an optional
+// byte order mark.
+func ExtractFrontMatter(b []byte) (events []*Event, rest []byte, err error) {
+	start := 0
+	if bytes.HasPrefix(b, utf8BOM) {
+		start = len(utf8BOM)
+	}
+	next, _ := nextLine(b, start)
+	if !isMarkerLine(b[start:next], "---") {
+		return nil, b, nil
+	}
+	end := -1
+	for i := next; i < len(b); i = next {
+		next, _ = nextLine(b, i)
+		if isMarkerLine(b[i:next], "---") || isMarkerLine(b[i:next], "...") {
+			end = next
+			break
+		}
+	}
+	if end < 0 {
+		return nil, nil, fmt.Errorf("front matter has no closing \"---\" or \"...\" line")
+	}
+
+	parser, err := NewParserFromBytes(b[:end:end])
+	if err != nil {
+		return nil, nil, err
+	}
+	defer parser.Close()
+	if events, err = parser.NextDocument(); err != nil {
+		return nil, nil, err
+	}
+	if events == nil {
+		return nil, nil, fmt.Errorf("unexpected end of events")
+	}
+	return events, b[end:], nil
+}
+
+// isMarkerLine reports whether the given line holds only the given document
+// marker, followed by optional spaces and tabs and the line break
+func isMarkerLine(line []byte, marker string) bool {
+	return bytes.Equal(bytes.TrimRight(line, " \t\r\n"), []byte(marker))
+}
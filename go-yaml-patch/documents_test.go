@@ -0,0 +1,207 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+)
+
+// markdownBody is Markdown text that is not valid YAML
+const markdownBody = "`code` starts a line\n@mention, 'unmatched quote\n*emphasis*\n"
+
+func TestExtractFrontMatter(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		title string
+		rest  string
+	}{
+		{"markdown body", "---\ntitle: Hello\n---\n" + markdownBody, "Hello", markdownBody},
+		{"dots", "---\ntitle: Hello\n...\n" + markdownBody, "Hello", markdownBody},
+		{"byte order mark", "\ufeff---\ntitle: Hello\n---\n" + markdownBody, "Hello", markdownBody},
+		{"crlf", "---\r\ntitle: Hello\r\n---\r\nbody\r\n", "Hello", "body\r\n"},
+		{"nothing after", "---\ntitle: Hello\n---", "Hello", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, rest, err := ExtractFrontMatter([]byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(rest) != tt.rest {
+				t.Errorf("rest is %q, want %q", rest, tt.rest)
+			}
+			if title := scalarNamed(tt.title)(events); title == nil || title.Path != "/title" {
+				t.Errorf("front matter has no title %q", tt.title)
+			}
+		})
+	}
+
+	for _, src := range []string{"", "title: Hello\n", "# Heading\n---\n"} {
+		events, rest, err := ExtractFrontMatter([]byte(src))
+		if err != nil || events != nil || string(rest) != src {
+			t.Errorf("%q without front matter gave %d events, rest %q and error %v", src, len(events), rest, err)
+		}
+	}
+
+	if _, _, err := ExtractFrontMatter([]byte("---\ntitle: Hello\n" + markdownBody)); err == nil {
+		t.Error("front matter without a closing line gave no error")
+	}
+}
+
+func TestFirstDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		value    string
+		implicit bool
+	}{
+		{"invalid second document", "---\na: 1\n---\n@not yaml\n", "1", true},
+		{"invalid text after the marker", "---\na: 1\n--- @not yaml\n", "1", true},
+		{"explicit end", "a: 1\n...\n" + markdownBody, "1", false},
+		{"implicit start", "# comment\n%YAML 1.2\n---\na: 1\n---\n" + markdownBody, "1", true},
+		{"no marker", "a: 1\n", "1", true},
+		{"crlf", "---\r\na: 1\r\n---\r\n@not yaml\r\n", "1", true},
+		{"byte order mark", "\ufeffa: 1\n---\n@not yaml\n", "1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := FirstDocument(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) == 0 {
+				t.Fatal("no document")
+			}
+			if value := scalarNamed(tt.value)(events); value == nil || value.Path != "/a" {
+				t.Errorf("document has no value %q at /a", tt.value)
+			}
+			if end := events[len(events)-1]; end.Type != EventDocumentEnd || end.Implicit != tt.implicit {
+				t.Errorf("last event is %v, implicit %v, want a DOCUMENT-END with implicit %v", end.Type, end.Implicit, tt.implicit)
+			}
+		})
+	}
+
+	events, err := FirstDocument(strings.NewReader("# only a comment\n"))
+	if err != nil || events != nil {
+		t.Errorf("stream without a document gave %d events and error %v", len(events), err)
+	}
+}
+
+func TestCountDocuments(t *testing.T) {
+	tests := []struct {
+		src  string
+		want int
+	}{
+		{"", 0},
+		{"# only a comment\n", 0},
+		{"a: 1\n", 1},
+		{"---\n---\n", 2},
+		{"a\n---\nb\n...\n%YAML 1.1\n--- c\n", 3},
+	}
+	for _, tt := range tests {
+		n, err := CountDocuments(strings.NewReader(tt.src))
+		if err != nil {
+			t.Fatalf("%q: %v", tt.src, err)
+		}
+		if n != tt.want {
+			t.Errorf("%q has %d documents, want %d", tt.src, n, tt.want)
+		}
+	}
+	if _, err := CountDocuments(strings.NewReader("a\n---\nb: [\n")); err == nil {
+		t.Error("invalid second document gave no error")
+	}
+}
+
+func TestParseScalar(t *testing.T) {
+	tests := []struct {
+		src   string
+		value string
+		tag   string
+	}{
+		{"1.2.3\n", "1.2.3", yaml_STR_TAG},
+		{"42", "42", yaml_INT_TAG},
+		{"--- 'true'\n...\n", "true", yaml_STR_TAG},
+		{"# version\n2.5\n", "2.5", yaml_FLOAT_TAG},
+		{"!!str 7\n", "7", yaml_STR_TAG},
+		{"---\n", "", yaml_NULL_TAG},
+	}
+	for _, tt := range tests {
+		value, tag, err := ParseScalar(strings.NewReader(tt.src))
+		if err != nil {
+			t.Fatalf("%q: %v", tt.src, err)
+		}
+		if value != tt.value || tag != tt.tag {
+			t.Errorf("%q gave %q with tag %q, want %q with tag %q", tt.src, value, tag, tt.value, tt.tag)
+		}
+	}
+
+	for _, src := range []string{"", "a: 1\n", "[1]\n", "a\n---\nb\n", "'unclosed\n"} {
+		if _, _, err := ParseScalar(strings.NewReader(src)); err == nil {
+			t.Errorf("%q gave no error", src)
+		}
+	}
+}
+
+func TestSplitDocuments(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		docs    []string
+		markers []string
+	}{
+		{"single implicit", "a: 1\n", []string{"a: 1\n"}, []string{"a: 1\n"}},
+		{
+			"implicit then explicit start", "a: 1\n---\nb: 2\n",
+			[]string{"a: 1\n", "\nb: 2\n"},
+			[]string{"a: 1\n", "---\nb: 2\n"},
+		},
+		{
+			"explicit ends", "a: 1\n...\n---\nb: 2\n...\n",
+			[]string{"a: 1\n", "\nb: 2\n"},
+			[]string{"a: 1\n...", "---\nb: 2\n..."},
+		},
+		{
+			"comment after an explicit end", "a: 1\n...\n# about b\nb: 2\n",
+			[]string{"a: 1\n", "\n# about b\nb: 2\n"},
+			[]string{"a: 1\n...", "\n# about b\nb: 2\n"},
+		},
+		{
+			"directives", "%YAML 1.1\n--- a\n",
+			[]string{" a\n"},
+			[]string{"%YAML 1.1\n--- a\n"},
+		},
+		{
+			"empty document", "---\n---\na: 1\n",
+			[]string{"\n", "\na: 1\n"},
+			[]string{"---\n", "---\na: 1\n"},
+		},
+		{"byte order mark", "\ufeffa: 1\n---\nb\n", []string{"a: 1\n", "\nb\n"}, []string{"a: 1\n", "---\nb\n"}},
+		{"no documents", "# only a comment\n", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, options := range []SplitOptions{{}, {IncludeMarkers: true}} {
+				want := tt.docs
+				if options.IncludeMarkers {
+					want = tt.markers
+				}
+				docs, err := SplitDocumentsWithOptions([]byte(tt.src), options)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(docs) != len(want) {
+					t.Fatalf("got %d documents with %+v, want %d", len(docs), options, len(want))
+				}
+				for i := range want {
+					if string(docs[i]) != want[i] {
+						t.Errorf("document %d with %+v is %q, want %q", i, options, docs[i], want[i])
+					}
+				}
+			}
+		})
+	}
+
+	if _, err := SplitDocuments([]byte("a: 1\n---\nb: [\n")); err == nil {
+		t.Error("invalid second document gave no error")
+	}
+}
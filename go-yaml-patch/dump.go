@@ -0,0 +1,178 @@
+package yaml
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DumpEvents reads the remaining events from the parser and writes them to
+// w as a YAML-style listing, one FormatEvent entry per event separated by
+// blank lines
+func DumpEvents(w io.Writer, parser *Parser) error {
+	for {
+		event, err := parser.Next()
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			return nil
+		}
+		if _, err := fmt.Fprintln(w, FormatEvent(event)); err != nil {
+			return err
+		}
+	}
+}
+
+// FormatEvent returns a YAML-style description of the event as a list
+// entry, covering its type, position, comments and properties. Every line
+// ends with a newline.
+func FormatEvent(event *Event) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "- Event: %v\n", event.Type)
+	fmt.Fprintf(&b, "  Start: {Line: %d, Column: %d}\n",
+		event.StartMark.Line+1, event.StartMark.Column)
+	fmt.Fprintf(&b, "  End: {Line: %d, Column: %d}\n",
+		event.EndMark.Line+1, event.EndMark.Column)
+
+	// Print any comments associated with the event
+	if len(event.HeadComment) > 0 {
+		fmt.Fprintf(&b, "  HeadComment: %q\n", string(event.HeadComment))
+	}
+	if len(event.LineComment) > 0 {
+		fmt.Fprintf(&b, "  LineComment: %q\n", string(event.LineComment))
+	}
+	if len(event.FootComment) > 0 {
+		fmt.Fprintf(&b, "  FootComment: %q\n", string(event.FootComment))
+	}
+	if len(event.TailComment) > 0 {
+		fmt.Fprintf(&b, "  TailComment: %q\n", string(event.TailComment))
+	}
+
+	switch event.Type {
+	case EventScalar:
+		fmt.Fprintf(&b, "  Value: %q\n", event.Value)
+		if style := event.StyleString(); style != "" && style != "plain" {
+			fmt.Fprintf(&b, "  Style: %s\n", style)
+		}
+		if event.Tag != "" {
+			fmt.Fprintf(&b, "  Tag: %s\n", event.Tag)
+		}
+		if event.Anchor != "" {
+			fmt.Fprintf(&b, "  Anchor: %s\n", event.Anchor)
+		}
+		fmt.Fprintf(&b, "  Implicit: %v\n", event.Implicit)
+	case EventAlias:
+		fmt.Fprintf(&b, "  Anchor: %s\n", event.Anchor)
+	case EventSequenceStart, EventMappingStart:
+		if style := event.StyleString(); style != "" && style != "block" {
+			fmt.Fprintf(&b, "  Style: %s\n", style)
+		}
+		if event.Tag != "" {
+			fmt.Fprintf(&b, "  Tag: %s\n", event.Tag)
+		}
+		if event.Anchor != "" {
+			fmt.Fprintf(&b, "  Anchor: %s\n", event.Anchor)
+		}
+		fmt.Fprintf(&b, "  Implicit: %v\n", event.Implicit)
+	case EventDocumentStart, EventDocumentEnd:
+		fmt.Fprintf(&b, "  Implicit: %v\n", event.Implicit)
+	}
+	return b.String()
+}
+
+// WriteTestEvents reads the remaining events from the parser and writes
+// them to w in the event format of the YAML test suite, one event per
+// line, such as "+MAP {} &anchor <tag:yaml.org,2002:map>" or "=VAL 'text"
+func (p *Parser) WriteTestEvents(w io.Writer) error {
+	for {
+		event, err := p.Next()
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			return nil
+		}
+		if _, err := io.WriteString(w, formatTestEvent(event)+"\n"); err != nil {
+			return err
+		}
+	}
+}
+
+// formatTestEvent returns the test suite line for the event
+func formatTestEvent(event *Event) string {
+	var b strings.Builder
+	switch event.Type {
+	case EventStreamStart:
+		b.WriteString("+STR")
+	case EventStreamEnd:
+		b.WriteString("-STR")
+	case EventDocumentStart:
+		b.WriteString("+DOC")
+		if !event.Implicit {
+			b.WriteString(" ---")
+		}
+	case EventDocumentEnd:
+		b.WriteString("-DOC")
+		if !event.Implicit {
+			b.WriteString(" ...")
+		}
+	case EventMappingStart, EventSequenceStart:
+		if event.Type == EventMappingStart {
+			b.WriteString("+MAP")
+			if yaml_mapping_style_t(event.Style) == yaml_FLOW_MAPPING_STYLE {
+				b.WriteString(" {}")
+			}
+		} else {
+			b.WriteString("+SEQ")
+			if yaml_sequence_style_t(event.Style) == yaml_FLOW_SEQUENCE_STYLE {
+				b.WriteString(" []")
+			}
+		}
+		writeTestProperties(&b, event)
+	case EventMappingEnd:
+		b.WriteString("-MAP")
+	case EventSequenceEnd:
+		b.WriteString("-SEQ")
+	case EventScalar:
+		b.WriteString("=VAL")
+		writeTestProperties(&b, event)
+		switch yaml_scalar_style_t(event.Style) {
+		case yaml_SINGLE_QUOTED_SCALAR_STYLE:
+			b.WriteString(" '")
+		case yaml_DOUBLE_QUOTED_SCALAR_STYLE:
+			b.WriteString(` "`)
+		case yaml_LITERAL_SCALAR_STYLE:
+			b.WriteString(" |")
+		case yaml_FOLDED_SCALAR_STYLE:
+			b.WriteString(" >")
+		default:
+			b.WriteString(" :")
+		}
+		testEscaper.WriteString(&b, event.Value)
+	case EventAlias:
+		b.WriteString("=ALI *" + event.Anchor)
+	}
+	return b.String()
+}
+
+// writeTestProperties writes the anchor and tag of a node event
+func writeTestProperties(b *strings.Builder, event *Event) {
+	if event.Anchor != "" {
+		b.WriteString(" &" + event.Anchor)
+	}
+	if event.Tag != "" {
+		b.WriteString(" <" + event.fullTag() + ">")
+	}
+}
+
+// testEscaper escapes scalar values the way the test suite writes them
+var testEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"\x00", "\\0",
+	"\b", "\\b",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\t", "\\t",
+)
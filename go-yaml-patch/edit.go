@@ -0,0 +1,438 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EditScalar returns a copy of source with the scalar at the given path of
+// mapping keys and sequence indexes, as FindKey takes them, set to
+// newValue. Only the bytes of the scalar's value are replaced, located by
+// the byte offsets of its marks; its tag, anchor and everything around it
+// are left exactly as they were.
+//
+// The value is written in the scalar's own style where that style can hold
+// it and reads back as newValue, and double-quoted otherwise, as it is for
+// literal and folded scalars. A plain value is written plain even if it
+// then resolves to another type, so setting "8080" to "9090" keeps an
+// integer an integer.
+func EditScalar(source []byte, path []string, newValue string) ([]byte, error) {
+	span, err := findScalar(source, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []string
+	switch span.style {
+	case StylePlain:
+		candidates = append(candidates, newValue)
+	case StyleSingleQuoted:
+		if singleQuotable(newValue) {
+			candidates = append(candidates, "'"+strings.ReplaceAll(newValue, "'", "''")+"'")
+		}
+	}
+	candidates = append(candidates, strconv.Quote(newValue))
+
+	for _, text := range candidates {
+		out := replaceBytes(source, span.start, span.end, text)
+		// Check that the new text reads back as the value without changing
+		// the structure around it, as a plain ": " could
+		if check, err := findScalar(out, path); err == nil && check.value == newValue {
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot write %q as the scalar at %s", newValue, pathPointer(path))
+}
+
+// scalarSpan locates the value of a scalar in its source
+type scalarSpan struct {
+	start, end int
+	style      EventStyle
+	value      string
+}
+
+// findScalar returns the span of the scalar at the given path in source
+func findScalar(source []byte, path []string) (scalarSpan, error) {
+	p, event, err := parseTo(source, path)
+	if err != nil {
+		return scalarSpan{}, err
+	}
+	defer p.Close()
+
+	if event.Type != EventScalar {
+		return scalarSpan{}, fmt.Errorf("%s: %s is a %v, not a scalar",
+			position(event.StartMark), pathPointer(path), event.Type)
+	}
+	start, end := rawSpan(event)
+	if start < 0 {
+		return scalarSpan{}, fmt.Errorf("%s: %s has no value to replace",
+			position(event.StartMark), pathPointer(path))
+	}
+	return scalarSpan{start: start, end: end, style: event.Style, value: event.Value}, nil
+}
+
+// parseTo parses source as far as the node at the given path and returns
+// the parser, which the caller must close, and the node's first event
+func parseTo(source []byte, path []string) (*Parser, *Event, error) {
+	p, err := NewParserFromBytes(source, WithRawScalars())
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := p.FindKey(path...); err != nil {
+		p.Close()
+		return nil, nil, err
+	}
+	event, err := p.Next()
+	if err == nil && event == nil {
+		err = &KeyNotFoundError{Path: pathPointer(path)}
+	}
+	if err != nil {
+		p.Close()
+		return nil, nil, err
+	}
+	return p, event, nil
+}
+
+// rawSpan returns the byte offsets of the source of a scalar's value, or
+// -1 if it has none, as for a synthetic null. A block scalar runs through
+// the line breaks after its content, which are left out, so that whatever
+// replaces it still ends its line.
+func rawSpan(event *Event) (start, end int) {
+	if event.RawValue == nil {
+		return -1, -1
+	}
+	raw := event.RawValue
+	if event.Style == StyleLiteral || event.Style == StyleFolded {
+		raw = bytes.TrimRight(raw, " \t\r\n")
+	}
+	start = event.EndMark.ByteOffset - len(event.RawValue)
+	return start, start + len(raw)
+}
+
+// InsertKey returns a copy of source with a new entry for key added to the
+// mapping at the given path, which is empty for the root. The entry goes
+// after the mapping's last entry: on a line of its own at the indentation
+// of the other keys in a block mapping, and after a comma before the
+// closing brace in a flow mapping, such as the empty mapping "{}". The rest
+// of source is left exactly as it was.
+//
+// The key and value are written plain where they read back as themselves,
+// so a value of "8080" is an integer, and double-quoted otherwise. It is
+// an error for the mapping to have the key already; EditScalar changes an
+// existing value. A block mapping without entries has no indentation to
+// follow and is an error too, while a key with no value, as in "a:",
+// holds a null rather than a mapping.
+func InsertKey(source []byte, mappingPath []string, key, value string) ([]byte, error) {
+	site, err := findMapping(source, mappingPath)
+	if err != nil {
+		return nil, err
+	}
+	path := append(append([]string(nil), mappingPath...), key)
+	if p, _, err := parseTo(source, path); err == nil {
+		p.Close()
+		return nil, fmt.Errorf("%s already exists", pathPointer(path))
+	} else if _, ok := err.(*KeyNotFoundError); !ok {
+		return nil, err
+	}
+	before, err := firstDocument(source)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range scalarForms(key) {
+		for _, v := range scalarForms(value) {
+			out := replaceBytes(source, site.offset, site.offset, site.entry(k, v))
+			// The new text must add the entry and change nothing else
+			after, err := firstDocument(out)
+			if err != nil {
+				continue
+			}
+			diff, err := Diff(before, after)
+			if err == nil && len(diff) == 1 && diff[0].Op == DiffAdd &&
+				diff[0].Path == pathPointer(path) &&
+				len(diff[0].New) == 1 && diff[0].New[0].Value == value {
+				return out, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("cannot insert %q at %s", key, pathPointer(mappingPath))
+}
+
+// mappingSite is where a new entry goes in a mapping's source
+type mappingSite struct {
+	offset  int
+	flow    bool
+	empty   bool
+	indent  int
+	newline string // the line break a block entry ends with
+	prefix  string // a line break the block entry needs before it
+}
+
+// entry returns the text of an entry with the given key and value
+func (s mappingSite) entry(key, value string) string {
+	switch {
+	case s.flow && s.empty:
+		return key + ": " + value
+	case s.flow:
+		return ", " + key + ": " + value
+	}
+	return s.prefix + strings.Repeat(" ", s.indent) + key + ": " + value + s.newline
+}
+
+// findMapping returns where an entry is added to the mapping at the given
+// path in source
+func findMapping(source []byte, path []string) (mappingSite, error) {
+	m, err := readMapping(source, path)
+	if err != nil {
+		return mappingSite{}, err
+	}
+	site := mappingSite{flow: m.flow, empty: len(m.entries) == 0, offset: m.end}
+	if site.flow {
+		if !site.empty {
+			site.offset = m.entries[len(m.entries)-1].end
+		}
+		return site, nil
+	}
+	if site.empty {
+		return mappingSite{}, fmt.Errorf("%s: cannot find the entries of %s",
+			position(m.start.StartMark), pathPointer(path))
+	}
+	// A block entry goes on the line after the last content, indented like
+	// the first key
+	site.indent = m.entries[0].key.StartMark.Column
+	last := m.entries[len(m.entries)-1].end
+	site.offset, site.newline = nextLine(source, last)
+	if site.newline == "" {
+		site.newline, site.prefix = "\n", "\n"
+	}
+	return site, nil
+}
+
+// sourceMapping is a mapping located in its source
+type sourceMapping struct {
+	start   *Event // the MAPPING-START event
+	flow    bool
+	entries []sourceEntry
+	end     int // offset of the MAPPING-END event
+}
+
+// sourceEntry is a mapping entry located in its source
+type sourceEntry struct {
+	key *Event
+	// start is the offset of the key, and end the offset following the
+	// entry's last content
+	start, end int
+}
+
+// readMapping reads the mapping at the given path in source
+func readMapping(source []byte, path []string) (sourceMapping, error) {
+	p, event, err := parseTo(source, path)
+	if err != nil {
+		return sourceMapping{}, err
+	}
+	defer p.Close()
+	if event.Type != EventMappingStart {
+		return sourceMapping{}, fmt.Errorf("%s: %s is a %v, not a mapping",
+			position(event.StartMark), pathPointer(path), event.Type)
+	}
+
+	m := sourceMapping{start: event, flow: event.Style == StyleFlow}
+	// nodes counts the keys and values read, and flows holds whether each
+	// collection open within the mapping is in flow style, as only the end
+	// of a flow collection is in its source
+	nodes := 0
+	var flows []bool
+	for depth := 1; depth > 0; {
+		event, err := p.Next()
+		if err != nil {
+			return sourceMapping{}, err
+		}
+		if event == nil {
+			return sourceMapping{}, fmt.Errorf("unexpected end of events")
+		}
+		if depth == 1 && event.Type.IsContent() {
+			if nodes%2 == 0 {
+				m.entries = append(m.entries, sourceEntry{
+					key:   event,
+					start: event.StartMark.ByteOffset,
+					end:   -1,
+				})
+			}
+			nodes++
+		}
+		end := -1
+		switch {
+		case event.Type.IsCollectionStart():
+			flows = append(flows, event.Style == StyleFlow)
+			depth++
+		case event.Type.IsCollectionEnd():
+			if depth--; depth == 0 {
+				m.end = event.StartMark.ByteOffset
+				break
+			}
+			if flows[len(flows)-1] {
+				end = event.EndMark.ByteOffset
+			}
+			flows = flows[:len(flows)-1]
+		case event.Type == EventAlias:
+			end = event.EndMark.ByteOffset
+		default:
+			_, end = rawSpan(event)
+		}
+		if end >= 0 {
+			m.entries[len(m.entries)-1].end = end
+		}
+	}
+	return m, nil
+}
+
+// DeleteKey returns a copy of source without the entry at the given path,
+// its key and its value, and the rest of source exactly as it was.
+//
+// In a block mapping the lines of the entry are removed, from the key's
+// line through the end of the value's last line, with any comment lines
+// directly above the key, which are its head comment. Blank lines and
+// the comments after the value are kept, as they may belong to what
+// follows. A flow mapping loses the entry and the comma that separated it
+// from its neighbour. Deleting the only entry of a block mapping leaves
+// the empty mapping "{}" in its place.
+func DeleteKey(source []byte, path []string) ([]byte, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot delete the root node")
+	}
+	m, err := readMapping(source, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	k := -1
+	for i, entry := range m.entries {
+		if entry.key.Type == EventScalar && entry.key.Value == path[len(path)-1] {
+			k = i
+			break
+		}
+	}
+	if k < 0 {
+		return nil, &KeyNotFoundError{Path: pathPointer(path)}
+	}
+	entry := m.entries[k]
+
+	start, end, text := entry.start, entry.end, ""
+	switch {
+	case len(m.entries) == 1 && !m.flow:
+		text = "{}"
+	case len(m.entries) == 1:
+	case m.flow && k+1 < len(m.entries):
+		end = m.entries[k+1].start
+	case m.flow:
+		start = m.entries[k-1].end
+	case !onlySpace(source[lineStart(source, start):start]):
+		// A key after a "- " or other content shares its line with what
+		// comes before, so the next key takes its place
+		if k+1 < len(m.entries) {
+			end = m.entries[k+1].start
+		} else {
+			end, _ = nextLine(source, end)
+		}
+	default:
+		start = lineStart(source, start)
+		for start > 0 {
+			above := lineStart(source, start-1)
+			line := bytes.TrimSpace(source[above:start])
+			if len(line) == 0 || line[0] != '#' {
+				break
+			}
+			start = above
+		}
+		end, _ = nextLine(source, end)
+	}
+
+	out := replaceBytes(source, start, end, text)
+	before, err := firstDocument(source)
+	if err != nil {
+		return nil, err
+	}
+	after, err := firstDocument(out)
+	if err == nil {
+		diff, err := Diff(before, after)
+		if err == nil && len(diff) == 1 && diff[0].Op == DiffRemove &&
+			diff[0].Path == pathPointer(path) {
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot delete %s without changing the rest of the document",
+		pathPointer(path))
+}
+
+// lineStart returns the offset of the start of the line holding source[i]
+func lineStart(source []byte, i int) int {
+	return bytes.LastIndexByte(source[:i], '\n') + 1
+}
+
+// nextLine returns the offset of the line following source[i] and the line
+// break that ends the line, or len(source) and "" if it is the last line
+func nextLine(source []byte, i int) (int, string) {
+	n := bytes.IndexByte(source[i:], '\n')
+	switch {
+	case n < 0:
+		return len(source), ""
+	case i+n > 0 && source[i+n-1] == '\r':
+		return i + n + 1, "\r\n"
+	default:
+		return i + n + 1, "\n"
+	}
+}
+
+// onlySpace reports whether b holds only spaces and tabs
+func onlySpace(b []byte) bool {
+	return len(bytes.Trim(b, " \t")) == 0
+}
+
+// replaceBytes returns a copy of source with source[start:end] replaced by
+// text
+func replaceBytes(source []byte, start, end int, text string) []byte {
+	out := make([]byte, 0, len(source)-(end-start)+len(text))
+	out = append(out, source[:start]...)
+	out = append(out, text...)
+	return append(out, source[end:]...)
+}
+
+// firstDocument returns the events of the first document in source
+func firstDocument(source []byte) ([]*Event, error) {
+	p, err := NewParserFromBytes(source)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+	return p.NextDocument()
+}
+
+// scalarForms returns the ways to write a scalar for s to try, in order
+func scalarForms(s string) []string {
+	if s == "" {
+		return []string{strconv.Quote(s)}
+	}
+	return []string{s, strconv.Quote(s)}
+}
+
+// singleQuotable reports whether s can be written as a single-quoted
+// scalar on one line
+func singleQuotable(s string) bool {
+	for _, r := range s {
+		if r == '\n' || r == '\r' || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// pathPointer returns the JSON Pointer of a path of keys
+func pathPointer(path []string) string {
+	pointer := ""
+	for _, key := range path {
+		pointer += "/" + pathEscaper.Replace(key)
+	}
+	return pointer
+}
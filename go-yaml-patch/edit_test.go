@@ -0,0 +1,132 @@
+package yaml
+
+import "testing"
+
+func TestEditScalar(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		path  []string
+		value string
+		want  string
+	}{
+		{"plain", "a: 1\nb: x  # keep\n", []string{"b"}, "y", "a: 1\nb: y  # keep\n"},
+		{"plain stays an integer", "port: 8080\n", []string{"port"}, "9090", "port: 9090\n"},
+		{"single quoted", "a: 'x'\n", []string{"a"}, "it's", "a: 'it''s'\n"},
+		{"double quoted", "a: \"x\"\n", []string{"a"}, "y\tz", "a: \"y\\tz\"\n"},
+		{"plain needing quotes", "a: x\n", []string{"a"}, "b: c", "a: \"b: c\"\n"},
+		{"leading hash", "a: x\n", []string{"a"}, "#c", "a: \"#c\"\n"},
+		{"literal", "a: |\n  text\nb: 1\n", []string{"a"}, "new", "a: \"new\"\nb: 1\n"},
+		{"folded", "a: >\n  folded\n  text\nb: 1\n", []string{"a"}, "new", "a: \"new\"\nb: 1\n"},
+		{"crlf", "a: x\r\nb: y\r\n", []string{"a"}, "z", "a: z\r\nb: y\r\n"},
+		{"tag and anchor", "a: !!str &v 8080\nb: *v\n", []string{"a"}, "9090", "a: !!str &v 9090\nb: *v\n"},
+		{"sequence item", "list:\n- a\n- b\n", []string{"list", "1"}, "c", "list:\n- a\n- c\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := EditScalar([]byte(tt.src), tt.path, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("got %q, want %q", out, tt.want)
+			}
+		})
+	}
+
+	_, err := EditScalar([]byte("a: 1\n"), []string{"b"}, "2")
+	if _, ok := err.(*KeyNotFoundError); !ok {
+		t.Errorf("got error %v for a missing key, want a KeyNotFoundError", err)
+	}
+	if _, err := EditScalar([]byte("a: {b: 1}\n"), []string{"a"}, "2"); err == nil {
+		t.Error("editing a mapping as a scalar gave no error")
+	}
+}
+
+func TestInsertKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		path       []string
+		key, value string
+		want       string
+	}{
+		{"block", "a: 1\nb: 2\n", nil, "c", "3", "a: 1\nb: 2\nc: 3\n"},
+		{"no trailing newline", "a: 1", nil, "b", "2", "a: 1\nb: 2\n"},
+		{"crlf", "a: 1\r\n", nil, "b", "2", "a: 1\r\nb: 2\r\n"},
+		{"nested", "top:\n  a: 1\nnext: 2\n", []string{"top"}, "b", "x", "top:\n  a: 1\n  b: x\nnext: 2\n"},
+		{"under a sequence item", "- name: x\n  id: 1\n", []string{"0"}, "k", "v", "- name: x\n  id: 1\n  k: v\n"},
+		{"after a nested value", "a:\n  b: 1\n", nil, "c", "2", "a:\n  b: 1\nc: 2\n"},
+		{"empty flow", "a: {}\n", []string{"a"}, "k", "v", "a: {k: v}\n"},
+		{"flow", "a: {x: 1}\n", []string{"a"}, "k", "v", "a: {x: 1, k: v}\n"},
+		{"quoted value", "a: 1\n", nil, "b", "c: d", "a: 1\nb: \"c: d\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := InsertKey([]byte(tt.src), tt.path, tt.key, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("got %q, want %q", out, tt.want)
+			}
+		})
+	}
+
+	errors := []struct {
+		name string
+		src  string
+		path []string
+	}{
+		{"duplicate key", "a: 1\nb: 2\n", nil},
+		{"null value", "a:\n", []string{"a"}},
+		{"sequence", "a: [1]\n", []string{"a"}},
+	}
+	for _, tt := range errors {
+		if _, err := InsertKey([]byte(tt.src), tt.path, "b", "x"); err == nil {
+			t.Errorf("%s: got no error", tt.name)
+		}
+	}
+}
+
+func TestDeleteKey(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		path []string
+		want string
+	}{
+		{"block", "a: 1\nb: 2\nc: 3\n", []string{"b"}, "a: 1\nc: 3\n"},
+		{"head comment", "a: 1\n# about b\n# more\nb: 2\nc: 3\n", []string{"b"}, "a: 1\nc: 3\n"},
+		{"comments after the value", "a: 1\nb: 2\n\n# foot\nc: 3\n", []string{"b"}, "a: 1\n\n# foot\nc: 3\n"},
+		{"nested value", "a: 1\nb:\n  x: [1, 2]\n  y: 2\nc: 3\n", []string{"b"}, "a: 1\nc: 3\n"},
+		{"last entry", "a: 1\nb: 2\n", []string{"b"}, "a: 1\n"},
+		{"shared line", "- a: 1\n  b: 2\n", []string{"0", "a"}, "- b: 2\n"},
+		{"shared line, last key", "- a: 1\n  b: 2\n- c\n", []string{"0", "b"}, "- a: 1\n- c\n"},
+		{"only block entry", "a:\n  b: 1\n", []string{"a", "b"}, "a:\n  {}\n"},
+		{"flow first", "{a: 1, b: 2, c: 3}\n", []string{"a"}, "{b: 2, c: 3}\n"},
+		{"flow middle", "{a: 1, b: 2, c: 3}\n", []string{"b"}, "{a: 1, c: 3}\n"},
+		{"flow last", "{a: 1, b: 2, c: 3}\n", []string{"c"}, "{a: 1, b: 2}\n"},
+		{"only flow entry", "a: {x: 1}\n", []string{"a", "x"}, "a: {}\n"},
+		{"crlf", "a: 1\r\nb: 2\r\nc: 3\r\n", []string{"b"}, "a: 1\r\nc: 3\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := DeleteKey([]byte(tt.src), tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("got %q, want %q", out, tt.want)
+			}
+		})
+	}
+
+	_, err := DeleteKey([]byte("a: 1\n"), []string{"b"})
+	if _, ok := err.(*KeyNotFoundError); !ok {
+		t.Errorf("got error %v for a missing key, want a KeyNotFoundError", err)
+	}
+	if _, err := DeleteKey([]byte("a: 1\n"), nil); err == nil {
+		t.Error("deleting the root gave no error")
+	}
+}
@@ -0,0 +1,425 @@
+package yaml
+
+import (
+	"fmt"
+	"io"
+)
+
+// EmitterOptions controls how an Emitter serializes events.
+//
+// Parsing a document and emitting the resulting events with the zero
+// options is the canonical round-trip path: the output is semantically
+// identical to the input, but the emitter picks its own presentation.
+type EmitterOptions struct {
+	// Canonical makes the emitter write the spec's canonical form, with
+	// explicit tags, double-quoted scalars and flow collections.
+	Canonical bool
+	// Preserve makes the emitter reuse each event's Style and comment
+	// fields verbatim. Styles the emitter cannot honor in context (such as
+	// a literal scalar inside a flow collection) fall back to a quoted
+	// style.
+	Preserve bool
+
+	// Indent is the number of spaces per indentation level, from 2 to
+	// 9. Zero selects the default of 2.
+	Indent int
+	// BestWidth is the column at which the emitter prefers to wrap long
+	// scalars; -1 disables wrapping. Zero selects the default of 80.
+	BestWidth int
+	// ExplicitDocStart writes the "---" marker at the start of every
+	// document, even when the DOCUMENT-START event is implicit
+	ExplicitDocStart bool
+	// ExplicitDocEnd writes the "..." marker at the end of every document,
+	// even when the DOCUMENT-END event is implicit
+	ExplicitDocEnd bool
+
+	// ForceFlow and ForceBlock write every collection in flow or block
+	// style respectively, overriding the event styles. They cannot both
+	// be set. The emitter still writes collections nested in a flow
+	// collection as flow.
+	ForceFlow  bool
+	ForceBlock bool
+	// ForceScalarStyle, when not zero, is the style every scalar is
+	// written in, such as StyleDoubleQuoted. Scalars that cannot be
+	// written in that style fall back to a quoted style.
+	ForceScalarStyle EventStyle
+
+	// LineEnding is the line break the emitter writes, "\n", "\r\n" or
+	// "\r", such as the one Parser.LineEnding reports. Empty selects the
+	// default of "\n".
+	LineEnding string
+	// OmitTrailingNewline drops the line break that ends the output, to
+	// write back input that had none, as Parser.TrailingNewline reports.
+	// The emitter holds back trailing line breaks until more output
+	// follows or it is closed.
+	OmitTrailingNewline bool
+}
+
+// Emitter provides a high-level interface for writing YAML event streams
+type Emitter struct {
+	emitter  yaml_emitter_t
+	options  EmitterOptions
+	stack    []emitterFrame
+	trailing *trailingBreakWriter // set with OmitTrailingNewline
+	anchors  map[string]bool      // anchors written so far in the document
+}
+
+// NewEmitter creates a new YAML emitter writing to the given writer. Event
+// styles and comments are preserved.
+func NewEmitter(writer io.Writer) (*Emitter, error) {
+	return NewEmitterWithOptions(writer, EmitterOptions{Preserve: true})
+}
+
+// NewEmitterWithOptions creates a new YAML emitter writing to the given
+// writer using the given options
+func NewEmitterWithOptions(writer io.Writer, options EmitterOptions) (*Emitter, error) {
+	if options.ForceFlow && options.ForceBlock {
+		return nil, fmt.Errorf("emitter error: ForceFlow and ForceBlock cannot both be set")
+	}
+	if options.Indent != 0 && (options.Indent < 2 || options.Indent > 9) {
+		return nil, fmt.Errorf("emitter error: indent %d is not between 2 and 9", options.Indent)
+	}
+	lineBreak, ok := lineBreakStyles[options.LineEnding]
+	if !ok {
+		return nil, fmt.Errorf("emitter error: unsupported line ending %q", options.LineEnding)
+	}
+	e := Emitter{options: options}
+	if options.OmitTrailingNewline {
+		e.trailing = &trailingBreakWriter{writer: writer}
+		writer = e.trailing
+	}
+	yaml_emitter_initialize(&e.emitter)
+	yaml_emitter_set_output_writer(&e.emitter, writer)
+	yaml_emitter_set_canonical(&e.emitter, options.Canonical)
+	if options.Indent != 0 {
+		yaml_emitter_set_indent(&e.emitter, options.Indent)
+	}
+	if options.BestWidth != 0 {
+		yaml_emitter_set_width(&e.emitter, options.BestWidth)
+	}
+	yaml_emitter_set_break(&e.emitter, lineBreak)
+	return &e, nil
+}
+
+// lineBreakStyles maps the values of the LineEnding option to the line
+// breaks of the underlying emitter
+var lineBreakStyles = map[string]yaml_break_t{
+	"":     yaml_LN_BREAK,
+	"\n":   yaml_LN_BREAK,
+	"\r\n": yaml_CRLN_BREAK,
+	"\r":   yaml_CR_BREAK,
+}
+
+// EmitCanonical writes the given event stream, from STREAM-START through
+// STREAM-END, to w in the canonical form defined by the YAML spec: every
+// node explicitly tagged, scalars double-quoted and collections in flow
+// style. Untagged nodes and those with the non-specific "!" tag are tagged
+// with their core schema resolution, so two semantically equal streams
+// produce the same output.
+func EmitCanonical(events []*Event, w io.Writer) error {
+	e, err := NewEmitterWithOptions(w, EmitterOptions{Canonical: true})
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		untagged := event.Tag == "" || event.Tag == "!"
+		if untagged && (event.Type == EventScalar || event.Type.IsCollectionStart()) {
+			tagged := *event
+			tagged.Tag = event.ResolvedTag()
+			event = &tagged
+		}
+		if err := e.Emit(event); err != nil {
+			e.Close()
+			return err
+		}
+	}
+	return e.Close()
+}
+
+// EmitDocuments writes the given documents to w as a single stream using
+// the given options. Each document is a slice of events, either from
+// DOCUMENT-START through DOCUMENT-END as NextDocument returns them, or just
+// the events of its root node, which are wrapped in implicit document
+// events. Every document after the first starts with a "---" marker.
+func EmitDocuments(docs [][]*Event, w io.Writer, options EmitterOptions) error {
+	e, err := NewEmitterWithOptions(w, options)
+	if err != nil {
+		return err
+	}
+	emit := func(event *Event) error {
+		if event.Type == EventStreamStart || event.Type == EventStreamEnd {
+			return fmt.Errorf("emitter error: unexpected %v event in a document", event.Type)
+		}
+		return e.Emit(event)
+	}
+
+	err = e.Emit(NewStreamStart())
+	for _, doc := range docs {
+		if err != nil {
+			break
+		}
+		wrap := len(doc) == 0 || doc[0].Type != EventDocumentStart
+		if wrap {
+			err = emit(NewDocumentStart(true))
+		}
+		for _, event := range doc {
+			if err == nil {
+				err = emit(event)
+			}
+		}
+		if wrap && err == nil {
+			err = emit(NewDocumentEnd(true))
+		}
+	}
+	if err == nil {
+		err = e.Emit(NewStreamEnd())
+	}
+	if closeErr := e.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Emit writes the given event to the YAML stream. Events must arrive in the
+// same order the Parser produces them, starting with STREAM-START and ending
+// with STREAM-END.
+//
+// Emit is incremental: events can be built and emitted one at a time, for
+// example with constructors such as NewScalar and NewMappingStart, and
+// nothing needs to hold the whole stream. The emitter only looks a few
+// events ahead to choose a layout, writing output whenever its buffer
+// fills; Flush writes whatever is complete so far.
+//
+// Anchors on scalar and collection start events are written as "&anchor",
+// and alias events as "*anchor". An alias must refer to an anchor written
+// earlier in the same document; otherwise Emit returns an
+// UndefinedAnchorError and writes nothing.
+func (e *Emitter) Emit(event *Event) error {
+	if err := e.checkAnchor(event); err != nil {
+		return err
+	}
+	var yamlEvent yaml_event_t
+
+	switch event.Type {
+	case EventStreamStart:
+		yamlEvent.typ = yaml_STREAM_START_EVENT
+		yamlEvent.encoding = yaml_UTF8_ENCODING
+	case EventStreamEnd:
+		yamlEvent.typ = yaml_STREAM_END_EVENT
+	case EventDocumentStart:
+		yamlEvent.typ = yaml_DOCUMENT_START_EVENT
+		yamlEvent.implicit = event.Implicit && !e.options.ExplicitDocStart
+	case EventDocumentEnd:
+		yamlEvent.typ = yaml_DOCUMENT_END_EVENT
+		yamlEvent.implicit = event.Implicit && !e.options.ExplicitDocEnd
+	case EventAlias:
+		yamlEvent.typ = yaml_ALIAS_EVENT
+		yamlEvent.anchor = []byte(event.Anchor)
+	case EventScalar:
+		yamlEvent.typ = yaml_SCALAR_EVENT
+		yamlEvent.value = []byte(event.Value)
+		yamlEvent.anchor = []byte(event.Anchor)
+		yamlEvent.tag = []byte(event.fullTag())
+		yamlEvent.implicit = event.Implicit || event.PlainImplicit
+		// An untagged scalar is always implicit when quoted, even if the
+		// event was built without the flag
+		yamlEvent.quoted_implicit = event.QuotedImplicit || event.Tag == ""
+		yamlEvent.style = event.Style
+	case EventSequenceStart:
+		yamlEvent.typ = yaml_SEQUENCE_START_EVENT
+		yamlEvent.anchor = []byte(event.Anchor)
+		yamlEvent.tag = []byte(event.fullTag())
+		yamlEvent.implicit = event.Implicit
+		yamlEvent.style = event.Style
+	case EventSequenceEnd:
+		yamlEvent.typ = yaml_SEQUENCE_END_EVENT
+	case EventMappingStart:
+		yamlEvent.typ = yaml_MAPPING_START_EVENT
+		yamlEvent.anchor = []byte(event.Anchor)
+		yamlEvent.tag = []byte(event.fullTag())
+		yamlEvent.implicit = event.Implicit
+		yamlEvent.style = event.Style
+	case EventMappingEnd:
+		yamlEvent.typ = yaml_MAPPING_END_EVENT
+	default:
+		return fmt.Errorf("emitter error: cannot emit %v event", event.Type)
+	}
+
+	if !e.options.Preserve {
+		// Let the emitter choose the presentation style
+		yamlEvent.style = 0
+	}
+	e.forceStyle(&yamlEvent)
+	if e.options.Preserve {
+		yamlEvent.head_comment = event.HeadComment
+		yamlEvent.line_comment = event.LineComment
+		yamlEvent.foot_comment = event.FootComment
+		yamlEvent.tail_comment = event.TailComment
+		if err := e.placeComments(event, &yamlEvent); err != nil {
+			return err
+		}
+	}
+
+	if !yaml_emitter_emit(&e.emitter, &yamlEvent) {
+		return fmt.Errorf("emitter error: %v", e.emitter.problem)
+	}
+	return nil
+}
+
+// checkAnchor records the anchor of the given event, and returns an error
+// if it is an alias to an anchor not yet written in the document
+func (e *Emitter) checkAnchor(event *Event) error {
+	switch {
+	case event.Type == EventDocumentStart:
+		e.anchors = nil
+	case event.Type == EventAlias:
+		if !e.anchors[event.Anchor] {
+			return &UndefinedAnchorError{
+				Anchor: event.Anchor,
+				Path:   event.Path,
+				Mark:   event.StartMark,
+			}
+		}
+	case event.Anchor != "" && (event.Type == EventScalar || event.Type.IsCollectionStart()):
+		if e.anchors == nil {
+			e.anchors = make(map[string]bool)
+		}
+		e.anchors[event.Anchor] = true
+	}
+	return nil
+}
+
+// forceStyle applies the ForceFlow, ForceBlock and ForceScalarStyle options
+func (e *Emitter) forceStyle(yamlEvent *yaml_event_t) {
+	switch yamlEvent.typ {
+	case yaml_SEQUENCE_START_EVENT:
+		if e.options.ForceFlow {
+			yamlEvent.style = yaml_style_t(yaml_FLOW_SEQUENCE_STYLE)
+		} else if e.options.ForceBlock {
+			yamlEvent.style = yaml_style_t(yaml_BLOCK_SEQUENCE_STYLE)
+		}
+	case yaml_MAPPING_START_EVENT:
+		if e.options.ForceFlow {
+			yamlEvent.style = yaml_style_t(yaml_FLOW_MAPPING_STYLE)
+		} else if e.options.ForceBlock {
+			yamlEvent.style = yaml_style_t(yaml_BLOCK_MAPPING_STYLE)
+		}
+	case yaml_SCALAR_EVENT:
+		if e.options.ForceScalarStyle != 0 {
+			yamlEvent.style = e.options.ForceScalarStyle
+		}
+	}
+}
+
+// emitterFrame tracks an open collection while emitting
+type emitterFrame struct {
+	mapping bool
+	block   bool
+	count   int // child nodes started so far
+
+	// keyFoot is the foot comment of the latest mapping key, which the
+	// emitter writes as the tail comment of the next key or mapping end
+	keyFoot []byte
+}
+
+// placeComments moves comments from where the parser reports them to where
+// the underlying emitter writes them, following the same rules as the Node
+// decoder and encoder. The parser leaves the foot comment of a mapping
+// entry on its value, on the following key, or on the mapping end, while
+// the emitter only writes it correctly after a nested value when it is the
+// tail comment of the next key or of the mapping end. A collection end
+// event that does not close the open collection is an error.
+func (e *Emitter) placeComments(event *Event, yamlEvent *yaml_event_t) error {
+	var parent *emitterFrame
+	if len(e.stack) > 0 {
+		parent = &e.stack[len(e.stack)-1]
+	}
+
+	if event.Type.IsCollectionEnd() {
+		if parent == nil || parent.mapping != (event.Type == EventMappingEnd) {
+			return fmt.Errorf("emitter error: unexpected %v event", event.Type)
+		}
+		top := *parent
+		e.stack = e.stack[:len(e.stack)-1]
+		if top.mapping {
+			if top.keyFoot == nil {
+				top.keyFoot = yamlEvent.tail_comment
+			}
+			if top.block && top.count > 0 && yamlEvent.foot_comment != nil {
+				top.keyFoot = yamlEvent.foot_comment
+				yamlEvent.foot_comment = nil
+			}
+			yamlEvent.tail_comment = top.keyFoot
+		}
+		e.valueDone(yamlEvent)
+		return nil
+	}
+	if !event.Type.IsContent() {
+		return nil
+	}
+
+	if parent != nil && parent.mapping && parent.count%2 == 0 {
+		// A mapping key takes the foot comment of the previous entry as
+		// its tail, and hands its own foot comment on to the next key
+		var foot []byte
+		if !event.Type.IsCollectionStart() {
+			foot, yamlEvent.foot_comment = yamlEvent.foot_comment, nil
+		}
+		if parent.keyFoot == nil {
+			parent.keyFoot = yamlEvent.tail_comment
+		}
+		if parent.block && parent.count > 0 && foot != nil {
+			parent.keyFoot, foot = foot, nil
+		}
+		yamlEvent.tail_comment = parent.keyFoot
+		parent.keyFoot = foot
+	}
+	if parent != nil {
+		parent.count++
+	}
+
+	if event.Type.IsCollectionStart() {
+		e.stack = append(e.stack, emitterFrame{
+			mapping: event.Type == EventMappingStart,
+			block:   yaml_mapping_style_t(yamlEvent.style) != yaml_FLOW_MAPPING_STYLE,
+		})
+	} else {
+		e.valueDone(yamlEvent)
+	}
+	return nil
+}
+
+// valueDone moves the foot comment of a completed mapping value to its key,
+// unless the key already has one
+func (e *Emitter) valueDone(yamlEvent *yaml_event_t) {
+	if len(e.stack) == 0 {
+		return
+	}
+	parent := &e.stack[len(e.stack)-1]
+	if parent.mapping && parent.count > 0 && parent.count%2 == 0 &&
+		parent.keyFoot == nil && yamlEvent.foot_comment != nil {
+		parent.keyFoot, yamlEvent.foot_comment = yamlEvent.foot_comment, nil
+	}
+}
+
+// Flush writes any buffered output to the writer
+func (e *Emitter) Flush() error {
+	if !yaml_emitter_flush(&e.emitter) {
+		return fmt.Errorf("emitter error: %v", e.emitter.problem)
+	}
+	return nil
+}
+
+// Close flushes any buffered output and releases the emitter resources
+func (e *Emitter) Close() error {
+	defer yaml_emitter_delete(&e.emitter)
+	if !yaml_emitter_flush(&e.emitter) {
+		return fmt.Errorf("emitter error: %v", e.emitter.problem)
+	}
+	if e.trailing != nil {
+		if err := e.trailing.finish(); err != nil {
+			return fmt.Errorf("emitter error: %v", err)
+		}
+	}
+	return nil
+}
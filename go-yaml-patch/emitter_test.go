@@ -0,0 +1,270 @@
+package yaml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// parseEvents returns every event of the given stream
+func parseEvents(t testing.TB, src string, opts ...ParserOption) []*Event {
+	t.Helper()
+	p, err := NewParserFromString(src, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	var events []*Event
+	for {
+		event, err := p.Next()
+		if err != nil {
+			t.Fatalf("parsing %q: %v", src, err)
+		}
+		if event == nil {
+			return events
+		}
+		events = append(events, event)
+	}
+}
+
+// parseDocuments returns the events of each document of the given stream
+func parseDocuments(t testing.TB, src string, opts ...ParserOption) [][]*Event {
+	t.Helper()
+	p, err := NewParserFromString(src, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	var docs [][]*Event
+	for {
+		doc, err := p.NextDocument()
+		if err != nil {
+			t.Fatalf("parsing %q: %v", src, err)
+		}
+		if doc == nil {
+			return docs
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// emitEvents writes the given events with the given options
+func emitEvents(t testing.TB, events []*Event, options EmitterOptions) string {
+	t.Helper()
+	var b bytes.Buffer
+	e, err := NewEmitterWithOptions(&b, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range events {
+		if err := e.Emit(event); err != nil {
+			t.Fatalf("emitting %v: %v", event.Type, err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+// roundTripDocs are documents in the style of the YAML test suite, covering
+// the node kinds, scalar styles and stream layouts the emitter writes
+var roundTripDocs = []struct {
+	name string
+	src  string
+}{
+	{"block mapping", "a: 1\nb: two\n"},
+	{"block sequence", "- foo\n- bar\n- 3.5\n"},
+	{"nested block", "outer:\n  inner:\n  - a\n  - b: c\n"},
+	{"flow sequence", "list: [x, y, z]\n"},
+	{"flow mapping", "map: {x: 1, y: [2, 3]}\n"},
+	{"single quoted", "s: 'it''s'\n"},
+	{"double quoted", "d: \"tab\\tnewline\\n\"\n"},
+	{"literal", "text: |\n  line one\n  line two\n"},
+	{"literal strip", "text: |-\n  no trailing break\n"},
+	{"folded", "text: >\n  folded\n  lines\n"},
+	{"folded keep", "text: >+\n  kept\n\n"},
+	{"anchors", "base: &b {x: 1}\nuse: *b\nscalar: &s v\nagain: *s\n"},
+	{"tags", "str: !!str 123\nint: !!int \"7\"\nlist: !!seq [a]\n"},
+	{"local tag", "thing: !local {a: b}\n"},
+	{"null and bool", "n: ~\nb: true\n"},
+	{"top level scalar", "--- hello\n"},
+	{"multiple documents", "--- a\n--- [b]\n...\n--- {c: d}\n"},
+	{"explicit end", "key: value\n...\n"},
+	{"unicode", "name: \"café ☃\"\n"},
+}
+
+// TestEmitRoundTrip parses each document, emits the events and parses the
+// output again, which must give the same documents
+func TestEmitRoundTrip(t *testing.T) {
+	modes := []struct {
+		name    string
+		options EmitterOptions
+		diff    DiffOptions
+	}{
+		{"default", EmitterOptions{}, DiffOptions{}},
+		{"canonical", EmitterOptions{Canonical: true}, DiffOptions{}},
+		{"preserve", EmitterOptions{Preserve: true}, DiffOptions{Styles: true}},
+	}
+	for _, doc := range roundTripDocs {
+		for _, mode := range modes {
+			t.Run(doc.name+"/"+mode.name, func(t *testing.T) {
+				out := emitEvents(t, parseEvents(t, doc.src), mode.options)
+				want := parseDocuments(t, doc.src)
+				got := parseDocuments(t, out)
+				if len(got) != len(want) {
+					t.Fatalf("emitted %q has %d documents, want %d", out, len(got), len(want))
+				}
+				for i := range want {
+					ops, err := DiffWithOptions(want[i], got[i], mode.diff)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(ops) != 0 {
+						t.Errorf("document %d of %q emitted as %q differs at %q", i, doc.src, out, ops[0].Path)
+					}
+				}
+			})
+		}
+	}
+}
+
+// commentedConfigFile is a configuration file with comments in every place
+// the emitter has to put them back
+const commentedConfigFile = `# Application configuration
+
+# The server section
+server:
+  host: localhost  # bound to loopback only
+  ports: [80, 443]  # plain and TLS
+  # foot of ports
+limits:
+  # head of the first limit
+  connections: 100
+  timeout: 30  # seconds
+# foot of limits
+users:
+- alice  # admin
+# between users
+- bob
+# end of the document
+...
+`
+
+// TestEmitComments round-trips a commented config file through the
+// preserving emitter, which must write every comment back and give the
+// same document
+func TestEmitComments(t *testing.T) {
+	srcs := []string{commentedConfigFile}
+	for _, doc := range commentedDocs {
+		srcs = append(srcs, doc.src)
+	}
+	for _, src := range srcs {
+		events := parseEvents(t, src)
+		out := emitEvents(t, events, EmitterOptions{Preserve: true})
+		if got, want := sourceComments(out), sourceComments(src); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("emitted %q with comments %q, want %q", out, got, want)
+		}
+		again := parseDocuments(t, out)
+		for i, doc := range parseDocuments(t, src) {
+			if i >= len(again) {
+				t.Fatalf("emitted %q has only %d documents", out, len(again))
+			}
+			if ops, err := Diff(doc, again[i]); err != nil || len(ops) != 0 {
+				t.Errorf("document %d emitted as %q differs: %v %v", i, out, ops, err)
+			}
+		}
+	}
+}
+
+func TestEmitAnchors(t *testing.T) {
+	shared := NewMappingStart(StyleFlow)
+	shared.Anchor = "shared"
+	events := []*Event{
+		NewStreamStart(), NewDocumentStart(true), NewMappingStart(0),
+		NewScalar("base", 0), shared, NewScalar("x", 0), NewScalar("1", 0), NewMappingEnd(),
+		NewScalar("a", 0), NewAlias("shared"),
+		NewScalar("b", 0), NewAlias("shared"),
+		NewMappingEnd(), NewDocumentEnd(true), NewStreamEnd(),
+	}
+	out := emitEvents(t, events, EmitterOptions{})
+	if strings.Count(out, "&shared") != 1 || strings.Count(out, "*shared") != 2 {
+		t.Errorf("emitted %q, want one &shared and two *shared", out)
+	}
+	want := parseEvents(t, "base: &shared {x: 1}\na: *shared\nb: *shared\n")
+	if ops, err := Diff(want, parseEvents(t, out)); err != nil || len(ops) != 0 {
+		t.Errorf("emitted %q, which differs: %v %v", out, ops, err)
+	}
+}
+
+func TestEmitUndefinedAnchor(t *testing.T) {
+	defined := NewScalar("1", 0)
+	defined.Anchor = "s"
+	tests := []struct {
+		name   string
+		events []*Event
+		anchor string
+	}{
+		{
+			"never defined",
+			[]*Event{NewStreamStart(), NewDocumentStart(true), NewSequenceStart(0), NewAlias("missing")},
+			"missing",
+		},
+		{
+			"previous document",
+			[]*Event{
+				NewStreamStart(), NewDocumentStart(true), defined, NewDocumentEnd(true),
+				NewDocumentStart(true), NewAlias("s"),
+			},
+			"s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			e, err := NewEmitterWithOptions(&b, EmitterOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			last := len(tt.events) - 1
+			for _, event := range tt.events[:last] {
+				if err := e.Emit(event); err != nil {
+					t.Fatalf("emitting %v: %v", event.Type, err)
+				}
+			}
+			err = e.Emit(tt.events[last])
+			if u, ok := err.(*UndefinedAnchorError); !ok || u.Anchor != tt.anchor {
+				t.Errorf("got error %v for *%s, want an UndefinedAnchorError", err, tt.anchor)
+			}
+		})
+	}
+}
+
+func TestEmitUnmatchedEnd(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []*Event
+	}{
+		{"mapping end alone", []*Event{NewMappingEnd()}},
+		{"sequence end in a document", []*Event{NewStreamStart(), NewDocumentStart(true), NewSequenceEnd()}},
+		{"mapping end of a sequence", []*Event{NewStreamStart(), NewDocumentStart(true), NewSequenceStart(0), NewMappingEnd()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			e, err := NewEmitter(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			last := len(tt.events) - 1
+			for _, event := range tt.events[:last] {
+				if err := e.Emit(event); err != nil {
+					t.Fatalf("emitting %v: %v", event.Type, err)
+				}
+			}
+			if err := e.Emit(tt.events[last]); err == nil || !strings.HasPrefix(err.Error(), "emitter error: unexpected") {
+				t.Errorf("got error %v, want an unexpected event error", err)
+			}
+		})
+	}
+}
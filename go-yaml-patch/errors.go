@@ -0,0 +1,257 @@
+package yaml
+
+import "fmt"
+
+// ErrorType identifies the stage of the underlying parser that failed
+type ErrorType int
+
+const (
+	ErrorNone ErrorType = iota
+	ErrorMemory
+	ErrorReader
+	ErrorScanner
+	ErrorParser
+)
+
+func (e ErrorType) String() string {
+	switch e {
+	case ErrorMemory:
+		return "memory error"
+	case ErrorReader:
+		return "reader error"
+	case ErrorScanner:
+		return "scanner error"
+	case ErrorParser:
+		return "parser error"
+	default:
+		return "no error"
+	}
+}
+
+// ParseError describes a failure reported by the underlying YAML parser.
+// Lines and columns are 1-based; offsets index into the input. For parsers
+// created with NewParserMulti, Source is the index of the reader the
+// problem is in and lines count from the start of that reader.
+//
+// Reader errors (such as malformed UTF-8) report the byte offset of the bad
+// input in Offset; other errors report the character index of the problem.
+// The Context fields locate the construct being parsed when the problem
+// was found, and are zero when there is no context. Error reports both, as
+// in "did not find expected key (while parsing a block mapping at line 3,
+// column 1)".
+type ParseError struct {
+	Type    ErrorType
+	Problem string
+	Context string
+	Line    int
+	Column  int
+	Offset  int
+
+	ContextLine   int
+	ContextColumn int
+	ContextOffset int
+
+	Source int
+	Name   string // the source name given to NewParserNamed
+}
+
+// newParseError builds a ParseError from the parser's error state
+func newParseError(parser *yaml_parser_t) *ParseError {
+	e := &ParseError{
+		Problem: parser.problem,
+		Context: parser.context,
+		Line:    parser.problem_mark.line + 1,
+		Column:  parser.problem_mark.column + 1,
+		Offset:  parser.problem_mark.index,
+	}
+	if parser.context != "" {
+		e.ContextLine = parser.context_mark.line + 1
+		e.ContextColumn = parser.context_mark.column + 1
+		e.ContextOffset = parser.context_mark.index
+	}
+
+	switch parser.error {
+	case yaml_MEMORY_ERROR:
+		e.Type = ErrorMemory
+	case yaml_READER_ERROR:
+		// Reader errors carry no marks, only the offset of the bad input
+		e.Type = ErrorReader
+		e.Line = parser.mark.line + 1
+		e.Column = parser.mark.column + 1
+		e.Offset = parser.problem_offset
+	case yaml_SCANNER_ERROR:
+		e.Type = ErrorScanner
+	default:
+		e.Type = ErrorParser
+	}
+	return e
+}
+
+// parseError builds a ParseError from the error state of the parser,
+// locating it within the parser's sources
+func (p *Parser) parseError() *ParseError {
+	e := newParseError(&p.parser)
+	e.Name = p.name
+	if p.sources == nil {
+		return e
+	}
+	index := e.Offset
+	if e.Type == ErrorReader {
+		index = p.parser.mark.index
+	}
+	var line int
+	e.Source, line = p.sources.locate(index, e.Line-1)
+	e.Line = line + 1
+	if e.Context != "" {
+		_, line = p.sources.locate(e.ContextOffset, e.ContextLine-1)
+		e.ContextLine = line + 1
+	}
+	return e
+}
+
+func (e *ParseError) Error() string {
+	switch {
+	case e.Type == ErrorReader && e.Name != "":
+		return fmt.Sprintf("%s: %v at offset %d: %s", e.Name, e.Type, e.Offset, e.Problem)
+	case e.Type == ErrorReader:
+		return fmt.Sprintf("%v at offset %d: %s", e.Type, e.Offset, e.Problem)
+	case e.Name != "":
+		return fmt.Sprintf("%s:%d:%d: %v: %s%s", e.Name, e.Line, e.Column, e.Type, e.Problem, e.context())
+	default:
+		return fmt.Sprintf("%v: line %d, column %d: %s%s", e.Type, e.Line, e.Column, e.Problem, e.context())
+	}
+}
+
+// context formats the construct the problem was found in for the end of
+// the error message, or returns "" if there is none
+func (e *ParseError) context() string {
+	if e.Context == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s at line %d, column %d)", e.Context, e.ContextLine, e.ContextColumn)
+}
+
+// position formats a mark for the start of an error message, as
+// "name:line:column" when the mark carries a source name
+func position(m Mark) string {
+	if m.Name != "" {
+		return fmt.Sprintf("%s:%d:%d", m.Name, m.Line+1, m.Column+1)
+	}
+	return fmt.Sprintf("line %d, column %d", m.Line+1, m.Column+1)
+}
+
+// DuplicateKeyError is returned by Next when DetectDuplicateKeys is enabled
+// and a mapping repeats a key. Keys are compared by resolved tag and value.
+type DuplicateKeyError struct {
+	Key       string
+	Path      string
+	FirstMark Mark // where the key was first defined
+	Mark      Mark // where the key was repeated
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("%s: duplicate key %q (first defined at line %d, column %d)",
+		position(e.Mark), e.Key, e.FirstMark.Line+1, e.FirstMark.Column+1)
+}
+
+// CycleError is returned by Next when DetectAliasCycles is enabled and an
+// alias refers to an enclosing collection, so that expanding it would never
+// terminate
+type CycleError struct {
+	Anchor     string
+	Path       string
+	AnchorMark Mark // where the enclosing anchor was defined
+	Mark       Mark // where the alias appeared
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%s: alias *%s refers to an enclosing node (anchored at line %d, column %d)",
+		position(e.Mark), e.Anchor, e.AnchorMark.Line+1, e.AnchorMark.Column+1)
+}
+
+// UndefinedAnchorError is returned by Next when ValidateAliases is enabled
+// and an alias refers to an anchor not defined earlier in the document, and
+// by Emitter.Emit when asked to write such an alias
+type UndefinedAnchorError struct {
+	Anchor string
+	Path   string
+	Mark   Mark
+}
+
+func (e *UndefinedAnchorError) Error() string {
+	return fmt.Sprintf("%s: alias *%s refers to an undefined anchor",
+		position(e.Mark), e.Anchor)
+}
+
+// DepthLimitError is returned by Next when MaxDepth is set and a collection
+// would be nested deeper than the limit
+type DepthLimitError struct {
+	Limit int
+	Path  string
+	Mark  Mark
+}
+
+func (e *DepthLimitError) Error() string {
+	return fmt.Sprintf("%s: collection nested deeper than %d levels",
+		position(e.Mark), e.Limit)
+}
+
+// LimitExceededError is returned by Next when MaxEvents or MaxBytes is set
+// and the stream goes past the limit
+type LimitExceededError struct {
+	Limit int
+	Unit  string // "events" or "bytes"
+	Mark  Mark
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s: input exceeds the limit of %d %s",
+		position(e.Mark), e.Limit, e.Unit)
+}
+
+// TabIndentationError is returned by Next when RejectTabs is set and a tab
+// is used to indent a line
+type TabIndentationError struct {
+	Mark Mark // position of the tab
+}
+
+func (e *TabIndentationError) Error() string {
+	return fmt.Sprintf("%s: found a tab character used for indentation", position(e.Mark))
+}
+
+// ScalarTooLargeError is returned by Next when MaxScalarBytes is set and a
+// scalar's value is longer than the limit
+type ScalarTooLargeError struct {
+	Limit int
+	Size  int  // length of the value in bytes
+	Mark  Mark // start of the scalar's value
+}
+
+func (e *ScalarTooLargeError) Error() string {
+	return fmt.Sprintf("%s: scalar of %d bytes exceeds the limit of %d bytes",
+		position(e.Mark), e.Size, e.Limit)
+}
+
+// ExpansionLimitError is returned by Next when MaxAliasExpansions is set and
+// the aliases of a document would expand to more nodes than the limit
+type ExpansionLimitError struct {
+	Limit  int
+	Anchor string // the alias that went past the limit
+	Path   string
+	Mark   Mark
+}
+
+func (e *ExpansionLimitError) Error() string {
+	return fmt.Sprintf("%s: alias *%s expands the document by more than %d nodes",
+		position(e.Mark), e.Anchor, e.Limit)
+}
+
+// KeyNotFoundError is returned by FindKey when the stream has no node at
+// the given path
+type KeyNotFoundError struct {
+	Path string // JSON Pointer of the missing node
+}
+
+func (e *KeyNotFoundError) Error() string {
+	return fmt.Sprintf("no node at path %q", e.Path)
+}
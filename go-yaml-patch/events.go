@@ -1,6 +1,7 @@
 package yaml
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 )
@@ -49,20 +50,250 @@ func (e EventType) String() string {
 	}
 }
 
-// Event represents a YAML parser event
+// IsCollectionStart reports whether the event type opens a sequence or mapping
+func (e EventType) IsCollectionStart() bool {
+	return e == EventSequenceStart || e == EventMappingStart
+}
+
+// IsCollectionEnd reports whether the event type closes a sequence or mapping
+func (e EventType) IsCollectionEnd() bool {
+	return e == EventSequenceEnd || e == EventMappingEnd
+}
+
+// IsContent reports whether the event type begins a node: a scalar, an alias
+// or the start of a collection
+func (e EventType) IsContent() bool {
+	return e == EventScalar || e == EventAlias || e.IsCollectionStart()
+}
+
+// IsDocumentBoundary reports whether the event type starts or ends a document
+func (e EventType) IsDocumentBoundary() bool {
+	return e == EventDocumentStart || e == EventDocumentEnd
+}
+
+// Event represents a YAML parser event.
+//
+// Comments are attached to events the same way the emitter expects them:
+//
+//   - HeadComment holds the comment lines directly above a node, on the
+//     node's first event (the key scalar for a mapping entry). Comments at
+//     the top of a document that are separated from its content by a blank
+//     line belong to DOCUMENT-START instead.
+//   - LineComment holds the comment at the end of the line the node is on.
+//   - FootComment holds the comment lines that follow a node at its own
+//     indentation, before a blank line or the next node.
+//   - TailComment holds the foot comment of the previous mapping value when
+//     the value ends with a dedent. It is carried by the following key, or
+//     by MAPPING-END after the last entry.
+//
+// Tag is empty for untagged nodes and "!" for nodes with the non-specific
+// tag, which makes a scalar a string. Other tags are reported as written,
+// such as "!!str" or "!e!foo", unless the ResolveTags option asks for them
+// in full; ShortTag always holds the written form. On scalar events Implicit reports
+// whether the tag follows from the value: it is true for untagged plain
+// scalars, which resolve by value, and false for tagged and quoted ones.
+// On document events it reports whether the "---" or "..." marker was left
+// out. On collection start events it reports whether the collection is
+// untagged: "{a: 1}" is implicit while "!!map {a: 1}" and "! {a: 1}" are
+// not, and the emitter writes the tag of a collection only when it is not
+// implicit.
+//
+// Scalar events also carry the two implicit flags of the underlying
+// events, which tell the emitter when it may leave out the tag:
+// PlainImplicit when the scalar is written plain, the same as Implicit,
+// and QuotedImplicit when it is written in any other style, which is true
+// for untagged quoted and block scalars.
+//
+// Events returned by the parser belong to the caller and stay valid after
+// later calls to Next: the underlying event is released before Next returns
+// and no field refers to memory the parser reuses. Use Clone to modify an
+// event without affecting other references to it.
 type Event struct {
-	Type       EventType
-	Value      string
-	Anchor     string
-	Tag        string
-	Style      yaml_style_t
-	Implicit   bool
-	StartMark  Mark
-	EndMark    Mark
+	Type        EventType
+	Value       string
+	Anchor      string
+	Tag         string // as written, or in full with ResolveTags
+	ShortTag    string // as written, e.g. "!!str" or "!e!foo"
+	Style       EventStyle
+	Implicit    bool
+	StartMark   Mark
+	EndMark     Mark
 	HeadComment []byte
 	LineComment []byte
 	FootComment []byte
 	TailComment []byte
+
+	// PlainImplicit and QuotedImplicit are the implicit flags of scalar
+	// events, described above
+	PlainImplicit  bool
+	QuotedImplicit bool
+
+	// BlockChomping and BlockIndent hold the chomping indicator and the
+	// explicit indentation indicator, or 0, of literal and folded scalars.
+	// They are only known for parsers reading UTF-8 from a byte slice or
+	// string; otherwise BlockChomping is ChompingNone.
+	BlockChomping Chomping
+	BlockIndent   int
+
+	// RawValue holds the source bytes of a scalar, from the start of its
+	// value through its end, including any quotes or block header but not
+	// its tag or anchor. It is only set when the RawScalars option is on
+	// and the parser reads from a byte slice or string, and it refers into
+	// that input.
+	RawValue []byte
+
+	// SyntheticNull is set on the empty plain scalars that the parser
+	// inserts where a node has no content, such as the root of a document
+	// holding only comments, as in "--- # comment", or the value of "key:".
+	// It tells them apart from a null written out as "null" or "~".
+	SyntheticNull bool
+
+	// VersionMajor and VersionMinor hold the %YAML version of the document
+	// on DOCUMENT-START events. Documents without a %YAML directive report
+	// 1.1, the version the underlying parser implements.
+	VersionMajor int
+	VersionMinor int
+
+	// TagDirectives holds the %TAG directives declared by the document on
+	// DOCUMENT-START events. The default "!" and "!!" handles are implied
+	// and not listed.
+	TagDirectives []TagDirective
+
+	// Depth is the nesting level of the event. The stream and document
+	// level is 0; collection start and end events report the level of the
+	// collection itself and everything inside it is one level deeper.
+	Depth int
+
+	// Path is the JSON Pointer (RFC 6901) of the node the event belongs
+	// to, such as "/services/web/ports/0". Document-level events and the
+	// document root node have the empty path. A mapping key reports the
+	// path of the entry it names, and collection end events repeat the
+	// path of their start event. Keys that are not scalars are written as
+	// "*name" for an alias and "?" for a collection.
+	Path string
+
+	// InMapping is set on the events that begin a mapping key or value,
+	// and IsKey on those that begin a key. Both are false for sequence
+	// items, the document root node and non-content events.
+	InMapping bool
+	IsKey     bool
+
+	// IsMergeKey is set on mapping keys that are merge keys: a plain "<<"
+	// scalar, or any scalar tagged !!merge. A quoted "<<" is an ordinary
+	// string key.
+	IsMergeKey bool
+
+	// value holds the bytes of Value as the underlying parser produced
+	// them, for ValueBytes, and tag the tag as the parser resolved it
+	value []byte
+	tag   string
+
+	// comments is the storage of the comment fields of an Event reused by
+	// NextInto
+	comments []byte
+}
+
+// Chomping is the chomping indicator of a block scalar, which decides what
+// happens to its trailing line breaks
+type Chomping int
+
+const (
+	// ChompingNone is reported for events that are not block scalars, or
+	// whose header is not known
+	ChompingNone Chomping = iota
+	// ChompingClip keeps the final line break, when there is no indicator
+	ChompingClip
+	// ChompingStrip drops all trailing line breaks, for the "-" indicator
+	ChompingStrip
+	// ChompingKeep keeps all trailing line breaks, for the "+" indicator
+	ChompingKeep
+)
+
+func (c Chomping) String() string {
+	switch c {
+	case ChompingClip:
+		return "clip"
+	case ChompingStrip:
+		return "strip"
+	case ChompingKeep:
+		return "keep"
+	default:
+		return "none"
+	}
+}
+
+// TagDirective represents a %TAG directive mapping a tag handle such as
+// "!e!" to the prefix it expands to
+type TagDirective struct {
+	Handle string
+	Prefix string
+}
+
+// Clone returns a deep copy of the event that shares no memory with it
+func (e *Event) Clone() *Event {
+	clone := *e
+	clone.HeadComment = cloneBytes(e.HeadComment)
+	clone.LineComment = cloneBytes(e.LineComment)
+	clone.FootComment = cloneBytes(e.FootComment)
+	clone.TailComment = cloneBytes(e.TailComment)
+	clone.RawValue = cloneBytes(e.RawValue)
+	clone.comments = nil
+	if e.TagDirectives != nil {
+		clone.TagDirectives = append([]TagDirective(nil), e.TagDirectives...)
+	}
+	return &clone
+}
+
+// ValueBytes returns the value of a scalar event as bytes. For events read
+// by a parser it returns the buffer the underlying scanner built the value
+// in, saving the copy that []byte(e.Value) would make. That buffer belongs
+// to the event alone and stays valid as long as the event, but it may be
+// shared with copies made by Clone and must not be modified. Events whose
+// Value has been changed since, or that were not read by a parser, return
+// a new copy of Value.
+func (e *Event) ValueBytes() []byte {
+	if e.value != nil && string(e.value) == e.Value {
+		return e.value
+	}
+	return []byte(e.Value)
+}
+
+// cloneBytes copies b, keeping nil as nil
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// EventStyle is the presentation style of a scalar or collection event.
+// Its meaning depends on the event type, so the same value can stand for a
+// scalar style and a collection style.
+type EventStyle = yaml_style_t
+
+// Styles for the Style field of scalar events
+const (
+	StyleAny          = EventStyle(yaml_ANY_SCALAR_STYLE) // chosen by the emitter
+	StylePlain        = EventStyle(yaml_PLAIN_SCALAR_STYLE)
+	StyleSingleQuoted = EventStyle(yaml_SINGLE_QUOTED_SCALAR_STYLE)
+	StyleDoubleQuoted = EventStyle(yaml_DOUBLE_QUOTED_SCALAR_STYLE)
+	StyleLiteral      = EventStyle(yaml_LITERAL_SCALAR_STYLE)
+	StyleFolded       = EventStyle(yaml_FOLDED_SCALAR_STYLE)
+)
+
+// Styles for the Style field of sequence and mapping start events. The
+// sequence and mapping styles of the underlying parser share values, and
+// StyleAny lets the emitter choose here too.
+const (
+	StyleBlock = EventStyle(yaml_BLOCK_SEQUENCE_STYLE)
+	StyleFlow  = EventStyle(yaml_FLOW_SEQUENCE_STYLE)
+)
+
+// SetStyle sets the presentation style of the event, one of the scalar
+// styles for scalar events and StyleBlock or StyleFlow for collection
+// start events
+func (e *Event) SetStyle(style EventStyle) {
+	e.Style = style
 }
 
 // StyleString returns a human-readable representation of the style
@@ -106,59 +337,548 @@ func (e *Event) StyleString() string {
 	}
 }
 
-// Mark represents a position in the YAML input stream
+// Mark represents a position in the YAML input stream. Index and Column
+// count characters, not bytes; ByteOffset is the position in bytes within
+// the original input. ByteOffset is only known for parsers reading from a
+// byte slice or string and is -1 otherwise.
+//
+// A byte order mark at the start of the input is consumed before
+// STREAM-START and is not counted, so content at the start of the first
+// line is at column 0. ByteOffset still counts its bytes.
+//
+// Source is the index of the reader the position falls in for parsers
+// created with NewParserMulti, and 0 otherwise. Name is the source name
+// given to NewParserNamed; it is only used in error messages.
 type Mark struct {
-	Index  int
-	Line   int
-	Column int
+	Index      int
+	Line       int
+	Column     int
+	ByteOffset int
+	Source     int
+	Name       string
+}
+
+// Encoding selects the character encoding of the parser input
+type Encoding int
+
+const (
+	// EncodingAuto detects the encoding from the byte order mark and
+	// falls back to UTF-8 when there is none
+	EncodingAuto Encoding = iota
+	EncodingUTF8
+	EncodingUTF16LE
+	EncodingUTF16BE
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case EncodingUTF8:
+		return "UTF-8"
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	case EncodingUTF16BE:
+		return "UTF-16BE"
+	default:
+		return "auto"
+	}
 }
 
-// Parser provides a high-level interface for parsing YAML streams
+// yamlEncoding returns the underlying parser's encoding constant
+func (e Encoding) yamlEncoding() yaml_encoding_t {
+	switch e {
+	case EncodingUTF8:
+		return yaml_UTF8_ENCODING
+	case EncodingUTF16LE:
+		return yaml_UTF16LE_ENCODING
+	case EncodingUTF16BE:
+		return yaml_UTF16BE_ENCODING
+	default:
+		return yaml_ANY_ENCODING
+	}
+}
+
+// ParserOptions controls optional parser checks and behavior. The options
+// can be given to NewParserWithOptions as a whole, or one at a time as
+// ParserOption values to NewParser and the other constructors.
+type ParserOptions struct {
+	// DetectDuplicateKeys makes Next return a DuplicateKeyError when a
+	// mapping repeats a scalar key
+	DetectDuplicateKeys bool
+
+	// DetectAliasCycles makes Next return a CycleError when an alias
+	// refers to a collection that encloses it
+	DetectAliasCycles bool
+
+	// ValidateAliases makes Next return an UndefinedAnchorError when an
+	// alias refers to an anchor that does not appear earlier in the same
+	// document. Forward references are forbidden by YAML and are
+	// reported the same way.
+	ValidateAliases bool
+
+	// SkipComments leaves the comment fields of every event nil. The
+	// underlying scanner has no switch to stop collecting comments, so
+	// this only saves copying them into each event: it cuts allocations
+	// for commented input, but scanning takes as long as before and
+	// comment-free input parses no faster.
+	SkipComments bool
+
+	// Encoding forces the encoding of the input. The default detects it
+	// from the byte order mark, which is how UTF-16 input must be marked
+	// unless its encoding is given here. A byte order mark matching a
+	// forced encoding is still skipped.
+	Encoding Encoding
+
+	// MaxDepth makes Next return a DepthLimitError when a collection
+	// would be nested more than this many levels deep. A top-level
+	// collection is one level deep. Zero means no limit.
+	MaxDepth int
+
+	// MaxEvents and MaxBytes make Next return a LimitExceededError once
+	// the stream produces more than this many events, or once an event
+	// ends more than this many bytes into the input. Bytes are counted
+	// exactly for parsers reading from memory; for reader-based parsers
+	// the character index is used instead. Zero means no limit.
+	MaxEvents int
+	MaxBytes  int
+
+	// MaxAliasExpansions makes Next return an ExpansionLimitError when
+	// the aliases of a document would add more than this many nodes if
+	// they were replaced by copies of their anchored nodes. This guards
+	// consumers that expand aliases against "billion laughs" documents,
+	// whose nested aliases grow exponentially. Zero means no limit.
+	MaxAliasExpansions int
+
+	// MaxScalarBytes makes Next return a ScalarTooLargeError when the
+	// value of a scalar is longer than this many bytes, before the value
+	// is copied into an Event. The underlying scanner reads a scalar in
+	// one piece, so pair it with MaxBytes to also bound the memory that
+	// scanning takes. Zero means no limit.
+	MaxScalarBytes int
+
+	// ResolveTags makes the Tag of each event the tag in full, such as
+	// "tag:yaml.org,2002:str" for "!!str", with its handle expanded by the
+	// document's %TAG directives or the default "!" and "!!" handles and a
+	// verbatim "!<...>" tag unwrapped. By default Tag is the tag as
+	// written, which ShortTag always holds. The non-specific "!" tag is
+	// reported as "!" either way.
+	ResolveTags bool
+
+	// RawScalars makes parsers reading from a byte slice or string set
+	// the RawValue of scalar events
+	RawScalars bool
+
+	// RejectTabs makes parsers reading UTF-8 from a byte slice or string
+	// return a TabIndentationError when a line with content is indented
+	// with a tab, including the places where the underlying parser accepts
+	// one, such as inside flow collections or before a plain scalar's
+	// continuation line. Tabs inside quoted and block scalars are content
+	// and never rejected.
+	RejectTabs bool
+
+	// ResolveMergeKeys replaces each merge key entry of a mapping, such as
+	// "<<: *defaults" or "<<: [*a, *b]", with copies of the entries of the
+	// merged mappings that the mapping does not define itself, written
+	// just before its MAPPING-END. When several mappings are merged, the
+	// earlier ones take precedence. The copies keep the marks of their
+	// definition but carry the depth and path of their new place, and
+	// lose their anchors. Merging anything but mappings is an error.
+	ResolveMergeKeys bool
+
+	// ReadBufferSize is the number of bytes a reader-based parser asks its
+	// reader for on each refill. Larger buffers mean fewer reads on fast
+	// local files, smaller ones hand a slow network reader's input to the
+	// parser sooner. It must be at least MinReadBufferSize; zero keeps the
+	// underlying parser's default of 512 bytes. Parsers reading from a byte
+	// slice or string ignore it.
+	ReadBufferSize int
+}
+
+// MinReadBufferSize is the smallest ReadBufferSize a parser accepts
+const MinReadBufferSize = 64
+
+// Parser provides a high-level interface for parsing YAML streams. The zero
+// Parser is ready to use once Reset gives it an input.
 type Parser struct {
-	parser yaml_parser_t
-	done   bool
+	parser  yaml_parser_t
+	options ParserOptions
+	reader  *contextReader
+	input   []byte
+	blank   bool // the input was empty, and input holds a line break in its place
+	cursor  charCursor
+	done    bool
+	peeked  *Event
+	stack   []collectionFrame
+	path    string
+	last    *Event // the event most recently returned by Next
+	events  int    // events produced so far
+
+	// borrowed is set while NextInto parses into the caller's Event, which
+	// the parser must copy instead of keeping a reference to
+	borrowed bool
+
+	// tagDirectives holds the %TAG directives of the current document
+	tagDirectives []TagDirective
+
+	// anchors maps the anchors defined so far in the current document to
+	// the events that defined them
+	anchors map[string]*Event
+
+	// anchorEnds maps anchored collection start events of the current
+	// document to their end events, once seen
+	anchorEnds map[*Event]*Event
+
+	// anchorSizes maps the anchors of the current document to the number
+	// of nodes their expansion contains, and expansions counts the nodes
+	// added by expanding its aliases, when MaxAliasExpansions is set
+	anchorSizes map[string]int
+	expansions  int
+
+	// name is the source name given to NewParserNamed
+	name string
+
+	// errOffset is the byte offset of the last error returned by Next,
+	// when failed is set and the offset is known
+	errOffset int
+	failed    bool
+
+	// tabs is the progress of the RejectTabs check
+	tabs tabCheck
+
+	// merge resolves merge keys for the ResolveMergeKeys option
+	merge mergeState
+
+	// styles counts the node styles of the current document, and onEvent
+	// is the hook registered with OnEvent
+	styles  StyleStats
+	onEvent func(*Event)
+
+	// sources tracks the readers of a parser created by NewParserMulti
+	sources *multiReader
+
+	// stopStream cancels a running Stream and waits for it to finish, and
+	// streamDone is closed once it has
+	stopStream func()
+	streamDone <-chan struct{}
 }
 
-// NewParser creates a new YAML parser reading from the given reader
-func NewParser(reader io.Reader) (*Parser, error) {
-	var p Parser
+// NewParser creates a new YAML parser reading from the given reader with
+// the given options
+func NewParser(reader io.Reader, opts ...ParserOption) (*Parser, error) {
+	return NewParserWithOptions(reader, parserOptions(opts))
+}
+
+// NewParserNamed creates a new YAML parser reading from the given reader,
+// such as a file, and records name as its source. The name is reported in
+// every Mark and error, so error messages read like "config.yaml:12:4: ...".
+func NewParserNamed(reader io.Reader, name string, opts ...ParserOption) (*Parser, error) {
+	p, err := NewParser(reader, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.name = name
+	return p, nil
+}
+
+// Name returns the source name given to NewParserNamed
+func (p *Parser) Name() string {
+	return p.name
+}
+
+// NewParserWithOptions creates a new YAML parser reading from the given
+// reader using the given options
+func NewParserWithOptions(reader io.Reader, options ParserOptions) (*Parser, error) {
+	if size := options.ReadBufferSize; size != 0 && size < MinReadBufferSize {
+		return nil, fmt.Errorf("read buffer size %d is below the minimum of %d",
+			size, MinReadBufferSize)
+	}
+	p := Parser{options: options}
 	if !yaml_parser_initialize(&p.parser) {
 		return nil, fmt.Errorf("failed to initialize YAML parser")
 	}
-	yaml_parser_set_input_reader(&p.parser, reader)
+	p.setBufferSize()
+	p.reader = &contextReader{reader: reader}
+	yaml_parser_set_input_reader(&p.parser, p.reader)
+	p.setEncoding()
 	return &p, nil
 }
 
+// Reset discards all parser state and starts parsing a new stream from the
+// given reader, reusing the parser's input buffers. Nothing carries over
+// from the previous stream: pending comments, %TAG directives, any peeked
+// event and the OnEvent hook are all dropped.
+//
+// Reset also works on a zero Parser, so parsers can be kept in a sync.Pool
+// and reset with fresh input each time they are taken out.
+func (p *Parser) Reset(reader io.Reader) error {
+	if reader == nil {
+		return fmt.Errorf("cannot reset parser with a nil reader")
+	}
+	p.release()
+	if cap(p.parser.raw_buffer) == 0 || cap(p.parser.buffer) == 0 {
+		if !yaml_parser_initialize(&p.parser) {
+			return fmt.Errorf("failed to initialize YAML parser")
+		}
+	}
+	p.setBufferSize()
+	p.reader = &contextReader{reader: reader}
+	yaml_parser_set_input_reader(&p.parser, p.reader)
+	p.setEncoding()
+	return nil
+}
+
+// setEncoding applies the Encoding option to a freshly initialized parser.
+// A leading byte order mark is consumed before parsing starts whether the
+// encoding is forced or detected, so it never shows up in marks.
+func (p *Parser) setEncoding() {
+	if p.options.Encoding == EncodingAuto {
+		return
+	}
+	encoding := p.options.Encoding.yamlEncoding()
+	yaml_parser_set_encoding(&p.parser, encoding)
+	if p.reader != nil {
+		p.reader.breaks.encoding = encoding
+		p.reader.reader = &bomReader{
+			reader: bufio.NewReader(p.reader.reader),
+			bom:    byteOrderMark(encoding),
+		}
+	}
+}
+
+// setBufferSize sizes the input buffers of the underlying parser for the
+// ReadBufferSize option. As in libyaml, the buffer of decoded characters
+// holds three times the raw buffer, enough for any input encoding.
+func (p *Parser) setBufferSize() {
+	size := p.options.ReadBufferSize
+	if size == 0 || cap(p.parser.raw_buffer) == size {
+		return
+	}
+	p.parser.raw_buffer = make([]byte, 0, size)
+	p.parser.buffer = make([]byte, 0, size*3)
+}
+
+// release drops every reference the parser holds to its previous input,
+// including comments and events, keeping only the input buffers for reuse
+func (p *Parser) release() {
+	if p.stopStream != nil {
+		p.stopStream()
+		p.stopStream, p.streamDone = nil, nil
+	}
+	rawBuffer, buffer := p.parser.raw_buffer[:0], p.parser.buffer[:0]
+	p.parser = yaml_parser_t{raw_buffer: rawBuffer, buffer: buffer}
+	p.reader = nil
+	p.sources = nil
+	p.tabs = tabCheck{}
+	p.name = ""
+	p.errOffset, p.failed = 0, false
+	p.input = nil
+	p.blank = false
+	p.cursor = charCursor{}
+	p.done = false
+	p.peeked = nil
+	p.last = nil
+	p.events = 0
+	// Frames past the length may still point at events of the previous
+	// stream, so the whole backing array is cleared
+	stack := p.stack[:cap(p.stack)]
+	for i := range stack {
+		stack[i] = collectionFrame{}
+	}
+	p.stack = stack[:0]
+	p.path = ""
+	p.tagDirectives = nil
+	p.anchors = nil
+	p.anchorEnds = nil
+	p.anchorSizes = nil
+	p.expansions = 0
+	p.styles = StyleStats{}
+	p.onEvent = nil
+	p.merge = mergeState{}
+}
+
+// NewParserFromBytes creates a new YAML parser reading directly from the
+// given byte slice. The slice is not copied and must not be modified while
+// the parser is in use.
+func NewParserFromBytes(input []byte, opts ...ParserOption) (*Parser, error) {
+	return NewParserFromBytesWithOptions(input, parserOptions(opts))
+}
+
+// NewParserFromBytesWithOptions creates a new YAML parser reading directly
+// from the given byte slice using the given options. The slice is not
+// copied and must not be modified while the parser is in use.
+func NewParserFromBytesWithOptions(input []byte, options ParserOptions) (*Parser, error) {
+	p := Parser{options: options}
+	if !yaml_parser_initialize(&p.parser) {
+		return nil, fmt.Errorf("failed to initialize YAML parser")
+	}
+	if len(input) == 0 {
+		input = []byte{'\n'}
+		p.blank = true
+	}
+	p.input = input
+	p.setEncoding()
+	if options.Encoding != EncodingAuto {
+		// As for readers, drop a byte order mark the parser would otherwise
+		// count as a character
+		input = input[p.bomLength():]
+	}
+	yaml_parser_set_input_string(&p.parser, input)
+	return &p, nil
+}
+
+// NewParserFromString creates a new YAML parser reading from the given string
+func NewParserFromString(input string, opts ...ParserOption) (*Parser, error) {
+	return NewParserFromBytes([]byte(input), opts...)
+}
+
 // Next returns the next event in the YAML stream
 func (p *Parser) Next() (*Event, error) {
+	event := p.peeked
+	p.peeked = nil
+	if event == nil {
+		var err error
+		event, err = p.parse()
+		if err != nil || event == nil {
+			return event, err
+		}
+	}
+	p.path = event.Path
+	p.last = event
+	return event, nil
+}
+
+// NextInto reads the next event in the YAML stream into the given event
+// instead of allocating a new one, and returns false once the stream has
+// ended, on STREAM-END and after it. Passing the same Event to every call
+// reuses one buffer for all of its comments, and keeps its string fields
+// when the new event repeats them, which saves most of the allocations of
+// Next when reading many small documents.
+//
+// The fields of the event are only valid until the next call to NextInto
+// with it, which overwrites them; use Clone to keep an event. The parser
+// keeps copies of the events it tracks, such as anchored nodes, rather than
+// references to the caller's Event, so Anchors returns those copies.
+func (p *Parser) NextInto(event *Event) (bool, error) {
+	if p.options.ResolveMergeKeys && p.peeked == nil {
+		// Resolved events are queued by the parser, so they cannot be
+		// parsed into the caller's event
+		if _, err := p.Peek(); err != nil || p.peeked == nil {
+			return false, err
+		}
+	}
+	if peeked := p.peeked; peeked != nil {
+		// The peeked event may also be tracked by the parser, so it must
+		// not share storage that later calls overwrite
+		p.peeked = nil
+		*event = *peeked.Clone()
+	} else {
+		p.borrowed = true
+		ok, err := p.parseInto(event)
+		p.borrowed = false
+		if !ok {
+			return false, err
+		}
+	}
+	p.path = event.Path
+	p.last = event
+	return event.Type != EventStreamEnd, nil
+}
+
+// Path returns the JSON Pointer of the event most recently returned by Next
+func (p *Parser) Path() string {
+	return p.path
+}
+
+// Peek returns the next event in the YAML stream without consuming it, so
+// the following call to Next returns the same event
+func (p *Parser) Peek() (*Event, error) {
+	if p.peeked == nil {
+		event, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		p.peeked = event
+	}
+	return p.peeked, nil
+}
+
+// parse returns the next event of the stream for Next and Peek
+func (p *Parser) parse() (*Event, error) {
+	if p.options.ResolveMergeKeys {
+		return p.nextMerged()
+	}
+	return p.parseEvent()
+}
+
+// parseEvent reads the next event from the underlying parser
+func (p *Parser) parseEvent() (*Event, error) {
+	event := &Event{}
+	if ok, err := p.parseInto(event); !ok {
+		return nil, err
+	}
+	return event, nil
+}
+
+// parseInto reads the next event from the underlying parser into the given
+// event, keeping its strings where the new event repeats them. For NextInto
+// it also reuses the event's comment buffer. It returns false at the end of
+// the stream or on an error.
+func (p *Parser) parseInto(event *Event) (bool, error) {
 	if p.done {
-		return nil, nil
+		return false, nil
 	}
 
 	var yamlEvent yaml_event_t
-	if !yaml_parser_parse(&p.parser, &yamlEvent) {
-		if p.parser.error != yaml_NO_ERROR {
-			return nil, fmt.Errorf("parser error: %v", p.parser.problem)
+	var tailComment []byte
+	var first int // the start of the tokens consumed for the event
+	for {
+		p.reserveTokens()
+		first = p.parser.tokens_head
+		if !yaml_parser_parse(&p.parser, &yamlEvent) {
+			if p.parser.error != yaml_NO_ERROR {
+				err := p.parseError()
+				if err.Type == ErrorReader {
+					p.setErrorOffset(err.Offset)
+				} else {
+					p.setErrorOffset(p.byteOffset(err.Offset))
+				}
+				return false, err
+			}
+			p.done = true
+			return false, nil
+		}
+		if yamlEvent.typ != yaml_TAIL_COMMENT_EVENT {
+			break
+		}
+		// The parser reports the foot comment of a block mapping's value
+		// as a separate event before the next key or the mapping end. The
+		// emitter expects it as the tail comment of that following event.
+		tailComment = append(tailComment, yamlEvent.foot_comment...)
+		yaml_event_delete(&yamlEvent)
+	}
+
+	old := *event
+	*event = Event{
+		StartMark: p.mark(yamlEvent.start_mark),
+		EndMark:   p.mark(yamlEvent.end_mark),
+	}
+	if !p.options.SkipComments {
+		// yaml_event_delete only drops the references, but the parser
+		// splits a document's leading comments into slices that share one
+		// array, so an append to one event's comment could overwrite the
+		// next. Copy them so each event owns its comments.
+		tail := yamlEvent.tail_comment
+		if tailComment != nil {
+			tail = tailComment
+		}
+		if p.borrowed {
+			event.setComments(old.comments, yamlEvent.head_comment,
+				yamlEvent.line_comment, yamlEvent.foot_comment, tail)
+		} else {
+			event.HeadComment = cloneBytes(yamlEvent.head_comment)
+			event.LineComment = cloneBytes(yamlEvent.line_comment)
+			event.FootComment = cloneBytes(yamlEvent.foot_comment)
+			event.TailComment = cloneBytes(tail)
 		}
-		p.done = true
-		return nil, nil
-	}
-
-	event := &Event{
-		StartMark: Mark{
-			Index:  int(yamlEvent.start_mark.index),
-			Line:   int(yamlEvent.start_mark.line),
-			Column: int(yamlEvent.start_mark.column),
-		},
-		EndMark: Mark{
-			Index:  int(yamlEvent.end_mark.index),
-			Line:   int(yamlEvent.end_mark.line),
-			Column: int(yamlEvent.end_mark.column),
-		},
-		HeadComment: yamlEvent.head_comment,
-		LineComment: yamlEvent.line_comment,
-		FootComment: yamlEvent.foot_comment,
-		TailComment: yamlEvent.tail_comment,
 	}
 
 	switch yamlEvent.typ {
@@ -170,31 +890,56 @@ func (p *Parser) Next() (*Event, error) {
 	case yaml_DOCUMENT_START_EVENT:
 		event.Type = EventDocumentStart
 		event.Implicit = yamlEvent.implicit
+		event.VersionMajor, event.VersionMinor = 1, 1
+		if version := yamlEvent.version_directive; version != nil {
+			event.VersionMajor = int(version.major)
+			event.VersionMinor = int(version.minor)
+		}
+		p.tagDirectives = p.tagDirectives[:0]
+		for _, directive := range yamlEvent.tag_directives {
+			event.TagDirectives = append(event.TagDirectives, TagDirective{
+				Handle: string(directive.handle),
+				Prefix: string(directive.prefix),
+			})
+		}
+		p.tagDirectives = append(p.tagDirectives, event.TagDirectives...)
 	case yaml_DOCUMENT_END_EVENT:
 		event.Type = EventDocumentEnd
 		event.Implicit = yamlEvent.implicit
 	case yaml_ALIAS_EVENT:
 		event.Type = EventAlias
-		event.Anchor = string(yamlEvent.anchor)
+		event.Anchor = reuseString(old.Anchor, yamlEvent.anchor)
 	case yaml_SCALAR_EVENT:
+		if err := p.checkScalarSize(&yamlEvent); err != nil {
+			yaml_event_delete(&yamlEvent)
+			p.setErrorOffset(err.Mark.ByteOffset)
+			return false, err
+		}
 		event.Type = EventScalar
-		event.Value = string(yamlEvent.value)
-		event.Anchor = string(yamlEvent.anchor)
-		event.Tag = string(yamlEvent.tag)
-		event.Implicit = yamlEvent.implicit
+		event.Value = reuseString(old.Value, yamlEvent.value)
+		event.value = yamlEvent.value
+		event.Anchor = reuseString(old.Anchor, yamlEvent.anchor)
+		event.tag = reuseString(old.tag, yamlEvent.tag)
+		// The parser counts the non-specific tag as plain implicit, but
+		// it stops the value from being resolved and must be written out
+		event.Implicit = yamlEvent.implicit && event.tag != "!"
+		event.PlainImplicit = event.Implicit
+		event.QuotedImplicit = yamlEvent.quoted_implicit
 		event.Style = yaml_style_t(yamlEvent.scalar_style())
+		event.SyntheticNull = p.isEmptyScalar()
+		p.readScalarSource(event)
 	case yaml_SEQUENCE_START_EVENT:
 		event.Type = EventSequenceStart
-		event.Anchor = string(yamlEvent.anchor)
-		event.Tag = string(yamlEvent.tag)
+		event.Anchor = reuseString(old.Anchor, yamlEvent.anchor)
+		event.tag = reuseString(old.tag, yamlEvent.tag)
 		event.Implicit = yamlEvent.implicit
 		event.Style = yaml_style_t(yamlEvent.sequence_style())
 	case yaml_SEQUENCE_END_EVENT:
 		event.Type = EventSequenceEnd
 	case yaml_MAPPING_START_EVENT:
 		event.Type = EventMappingStart
-		event.Anchor = string(yamlEvent.anchor)
-		event.Tag = string(yamlEvent.tag)
+		event.Anchor = reuseString(old.Anchor, yamlEvent.anchor)
+		event.tag = reuseString(old.tag, yamlEvent.tag)
 		event.Implicit = yamlEvent.implicit
 		event.Style = yaml_style_t(yamlEvent.mapping_style())
 	case yaml_MAPPING_END_EVENT:
@@ -202,10 +947,72 @@ func (p *Parser) Next() (*Event, error) {
 	}
 
 	yaml_event_delete(&yamlEvent)
-	return event, nil
+	if event.tag != "" {
+		event.ShortTag = reuseString(old.ShortTag, p.tagSource(event, first))
+		event.Tag = event.ShortTag
+		if p.options.ResolveTags {
+			event.Tag = event.tag
+		}
+	}
+	if p.options.RejectTabs {
+		if err := p.checkTabs(event); err != nil {
+			p.setErrorOffset(err.Mark.ByteOffset)
+			return false, err
+		}
+	}
+	if err := p.checkLimits(event); err != nil {
+		p.setErrorOffset(event.StartMark.ByteOffset)
+		return false, err
+	}
+	if err := p.track(event); err != nil {
+		p.setErrorOffset(event.StartMark.ByteOffset)
+		return false, err
+	}
+	if p.onEvent != nil {
+		p.onEvent(event)
+	}
+	return true, nil
+}
+
+// setComments copies the given comments into the event, all in the single
+// buffer buf when it is large enough, and keeps that buffer for the next
+// call. Absent comments stay nil.
+func (e *Event) setComments(buf []byte, head, line, foot, tail []byte) {
+	n := len(head) + len(line) + len(foot) + len(tail)
+	if cap(buf) < n {
+		buf = make([]byte, 0, n)
+	}
+	buf = buf[:0]
+	slot := func(b []byte) []byte {
+		if b == nil {
+			return nil
+		}
+		start := len(buf)
+		buf = append(buf, b...)
+		return buf[start:len(buf):len(buf)]
+	}
+	e.HeadComment = slot(head)
+	e.LineComment = slot(line)
+	e.FootComment = slot(foot)
+	e.TailComment = slot(tail)
+	e.comments = buf
+}
+
+// reuseString returns old if it holds the same bytes as b, so that a value
+// repeated from the previous event of a reused Event, such as a mapping
+// key, does not allocate a new string
+func reuseString(old string, b []byte) string {
+	if old == string(b) {
+		return old
+	}
+	return string(b)
 }
 
 // Close releases the parser resources
 func (p *Parser) Close() {
+	if p.stopStream != nil {
+		p.stopStream()
+		p.stopStream, p.streamDone = nil, nil
+	}
 	yaml_parser_delete(&p.parser)
 }
@@ -0,0 +1,100 @@
+//go:build go1.23
+
+package yaml
+
+import "iter"
+
+// Events returns an iterator over the remaining events in the YAML stream.
+// Iteration stops cleanly at the end of the stream; a parser error is
+// yielded once with a nil event and ends the iteration.
+func (p *Parser) Events() iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		for {
+			event, err := p.Next()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if event == nil {
+				return
+			}
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Documents returns an iterator over the remaining documents in the YAML
+// stream, yielding the events of each document from DOCUMENT-START through
+// DOCUMENT-END. An empty document yields its start and end events around
+// the implicit null scalar the parser supplies for it. Iteration stops at
+// the end of the stream, or after yielding the first error.
+func (p *Parser) Documents() iter.Seq2[[]*Event, error] {
+	return func(yield func([]*Event, error) bool) {
+		for {
+			events, err := p.NextDocument()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if events == nil {
+				return
+			}
+			if !yield(events, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns an iterator over the remaining events in the YAML stream
+// for which pred returns true. Errors are always yielded and end the
+// iteration.
+//
+// Filtering out only some of the structural events, such as a
+// MAPPING-START without its MAPPING-END, leaves a stream that no longer
+// nests correctly and cannot be emitted or turned into nodes. To drop a
+// whole collection, call SkipSubtree instead once its start event has been
+// read.
+func (p *Parser) Filter(pred func(*Event) bool) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		for event, err := range p.Events() {
+			if err == nil && !pred(event) {
+				continue
+			}
+			if !yield(event, err) {
+				return
+			}
+		}
+	}
+}
+
+// Pipe returns an iterator over the remaining events in the YAML stream,
+// each passed through the given transforms in order. An event dropped by a
+// transform is not seen by the ones after it. An error from the parser or
+// a transform is yielded once and ends the iteration.
+func (p *Parser) Pipe(transforms ...EventTransform) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+	events:
+		for event, err := range p.Events() {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, transform := range transforms {
+				var keep bool
+				if event, keep, err = transform(event); err != nil {
+					yield(nil, err)
+					return
+				}
+				if !keep {
+					continue events
+				}
+			}
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+//go:build go1.23
+
+package yaml
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// pipeValues runs src through Pipe and returns the scalar values yielded,
+// separated by spaces, and the error, if any
+func pipeValues(t *testing.T, src string, transforms ...EventTransform) (string, error) {
+	t.Helper()
+	p, err := NewParserFromString(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	var values []string
+	for event, err := range p.Pipe(transforms...) {
+		if err != nil {
+			if event != nil {
+				t.Errorf("error %v yielded with an event", err)
+			}
+			return strings.Join(values, " "), err
+		}
+		if event.Type == EventScalar {
+			values = append(values, event.Value)
+		}
+	}
+	return strings.Join(values, " "), nil
+}
+
+func TestPipe(t *testing.T) {
+	// Transforms run in order
+	values, err := pipeValues(t, "a: x1\nb: [x2]\n",
+		ReplaceScalars(regexp.MustCompile("x"), "y"),
+		ReplaceScalars(regexp.MustCompile("y"), "z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a z1 b z2"; values != want {
+		t.Errorf("got %q, want %q", values, want)
+	}
+
+	// A dropped event is not seen by later transforms
+	seen := ""
+	values, err = pipeValues(t, "[a, b, c]\n",
+		func(in *Event) (*Event, bool, error) {
+			return in, in.Value != "b", nil
+		},
+		func(in *Event) (*Event, bool, error) {
+			if in.Type == EventScalar {
+				seen += in.Value
+			}
+			return in, true, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values != "a c" || seen != "ac" {
+		t.Errorf("got %q with %q seen, want the scalar b dropped", values, seen)
+	}
+
+	// An error from a transform ends the iteration
+	failed := errors.New("failed")
+	values, err = pipeValues(t, "[a, b, c]\n", func(in *Event) (*Event, bool, error) {
+		if in.Value == "b" {
+			return nil, false, failed
+		}
+		return in, true, nil
+	})
+	if err != failed || values != "a" {
+		t.Errorf("got %q and error %v, want a and the transform's error", values, err)
+	}
+
+	// So does a parser error
+	values, err = pipeValues(t, "[a, b\n", ReplaceScalars(regexp.MustCompile("a"), "b"))
+	if err == nil || !strings.HasPrefix(values, "b") {
+		t.Errorf("got %q and error %v, want the transformed values and a parser error", values, err)
+	}
+
+	// Breaking out leaves the rest of the stream to the parser
+	p, err := NewParserFromString("[a, b]\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	for event, err := range p.Pipe() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Type == EventSequenceStart {
+			break
+		}
+	}
+	if event := nextEvent(t, p); event.Value != "a" {
+		t.Errorf("got %v %q after breaking out, want the scalar a", event.Type, event.Value)
+	}
+}
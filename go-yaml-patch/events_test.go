@@ -0,0 +1,440 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// findScalarEvent returns the first scalar event with the given value
+func findScalarEvent(t testing.TB, events []*Event, value string) *Event {
+	t.Helper()
+	for _, event := range events {
+		if event.Type == EventScalar && event.Value == value {
+			return event
+		}
+	}
+	t.Fatalf("no scalar %q in the events", value)
+	return nil
+}
+
+func TestByteOffset(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		value string
+		span  string // the source text between the scalar's marks
+	}{
+		{"ascii", "key: value\n", "value", "value"},
+		{"emoji key", "🎉: party\n", "party", "party"},
+		{"accented", "café: crème brûlée\n", "crème brûlée", "crème brûlée"},
+		{"later line", "a: é\nb: 😀😀\nc: end\n", "end", "end"},
+		{"double quoted", "ключ: \"naïve\"\n", "naïve", "\"naïve\""},
+		{"flow", "[ü, 🎉, x]\n", "x", "x"},
+		{"comment", "# ñ 🎉 ö\nkey: value\n", "value", "value"},
+		{"bom", "\ufeffé: x\n", "x", "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := findScalarEvent(t, parseEvents(t, tt.src), tt.value)
+			start, end := event.StartMark.ByteOffset, event.EndMark.ByteOffset
+			if start < 0 || end > len(tt.src) || start > end {
+				t.Fatalf("byte offsets %d to %d are out of range", start, end)
+			}
+			if got := tt.src[start:end]; got != tt.span {
+				t.Errorf("source between the marks is %q, want %q", got, tt.span)
+			}
+		})
+	}
+}
+
+func TestByteOffsetReader(t *testing.T) {
+	p, err := NewParser(strings.NewReader("é: x\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	for {
+		event, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			return
+		}
+		if event.StartMark.ByteOffset != -1 {
+			t.Fatalf("%v ByteOffset from a reader is %d, want -1", event.Type, event.StartMark.ByteOffset)
+		}
+	}
+}
+
+func TestDocumentEndImplicit(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []bool // Implicit of each DOCUMENT-END
+	}{
+		{"a\n", []bool{true}},
+		{"a\n...\n", []bool{false}},
+		{"--- a\n...\n--- b\n--- c\n...\n--- d\n", []bool{false, true, false, true}},
+		{"a: 1\n--- b\n...\n", []bool{true, false}},
+		{"--- a\n...\n...\n--- b", []bool{false, true}},
+	}
+	for _, tt := range tests {
+		p, err := NewParserFromString(tt.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []bool
+		for {
+			event, err := p.Next()
+			if err != nil {
+				t.Fatalf("parsing %q: %v", tt.src, err)
+			}
+			if event == nil {
+				break
+			}
+			if event.Type == EventDocumentEnd {
+				got = append(got, event.Implicit)
+			}
+		}
+		p.Close()
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%q: DOCUMENT-END events are implicit %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+// TestCommentsStayValid parses a long commented stream and checks, once
+// it is all parsed, that every event still holds its own comments
+func TestCommentsStayValid(t *testing.T) {
+	const entries = 3000
+	var b strings.Builder
+	for i := 0; i < entries; i++ {
+		fmt.Fprintf(&b, "# head %d\nkey%d: value%d  # line %d\n", i, i, i, i)
+	}
+	events := parseEvents(t, b.String())
+
+	var scalars []*Event
+	for _, event := range events {
+		if event.Type == EventScalar {
+			scalars = append(scalars, event)
+		}
+	}
+	if len(scalars) != 2*entries {
+		t.Fatalf("got %d scalars, want %d", len(scalars), 2*entries)
+	}
+	for i := 0; i < entries; i++ {
+		key, value := scalars[2*i], scalars[2*i+1]
+		if want := fmt.Sprintf("# head %d", i); string(key.HeadComment) != want {
+			t.Fatalf("head comment of key%d is %q, want %q", i, key.HeadComment, want)
+		}
+		// The line comment belongs to the entry, whichever of its events
+		// carries it
+		line := string(key.LineComment) + string(value.LineComment)
+		if want := fmt.Sprintf("# line %d", i); line != want {
+			t.Fatalf("line comment of key%d is %q, want %q", i, line, want)
+		}
+	}
+}
+
+func TestByteOrderMark(t *testing.T) {
+	const src = "\ufeffhello\n"
+	tests := []struct {
+		name string
+		new  func() (*Parser, error)
+	}{
+		{"string", func() (*Parser, error) { return NewParserFromString(src) }},
+		{"reader", func() (*Parser, error) { return NewParser(strings.NewReader(src)) }},
+		{"forced encoding", func() (*Parser, error) { return NewParserFromString(src, WithEncoding(EncodingUTF8)) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.new()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+			for {
+				event, err := p.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if event == nil {
+					t.Fatal("no scalar")
+				}
+				if event.Type != EventScalar {
+					continue
+				}
+				if event.Value != "hello" {
+					t.Errorf("value is %q, want %q", event.Value, "hello")
+				}
+				if mark := event.StartMark; mark.Line != 0 || mark.Column != 0 || mark.Index != 0 {
+					t.Errorf("scalar starts at line %d, column %d, index %d, want all 0", mark.Line, mark.Column, mark.Index)
+				}
+				if offset := event.StartMark.ByteOffset; offset != -1 && offset != len("\ufeff") {
+					t.Errorf("scalar ByteOffset is %d, want %d", offset, len("\ufeff"))
+				}
+				return
+			}
+		})
+	}
+}
+
+func TestNonSpecificTag(t *testing.T) {
+	tests := []struct {
+		src      string
+		tag      string
+		implicit bool
+		resolved string
+	}{
+		{"! foo\n", "!", false, "tag:yaml.org,2002:str"},
+		{"! 12\n", "!", false, "tag:yaml.org,2002:str"},
+		{"!!str foo\n", "!!str", false, "tag:yaml.org,2002:str"},
+		{"foo\n", "", true, "tag:yaml.org,2002:str"},
+		{"12\n", "", true, "tag:yaml.org,2002:int"},
+		{"'12'\n", "", false, "tag:yaml.org,2002:str"},
+	}
+	for _, tt := range tests {
+		event := firstOfType(EventScalar)(parseEvents(t, tt.src))
+		if event.Tag != tt.tag || event.Implicit != tt.implicit || event.ResolvedTag() != tt.resolved {
+			t.Errorf("%q: got tag %q, implicit %v, resolved %q, want %q, %v, %q", tt.src,
+				event.Tag, event.Implicit, event.ResolvedTag(), tt.tag, tt.implicit, tt.resolved)
+		}
+	}
+}
+
+func TestCollectionImplicit(t *testing.T) {
+	tests := []struct {
+		src      string
+		typ      EventType
+		implicit bool
+	}{
+		{"!!map {a: 1}\n", EventMappingStart, false},
+		{"{a: 1}\n", EventMappingStart, true},
+		{"! {a: 1}\n", EventMappingStart, false},
+		{"--- !!map\na: 1\n", EventMappingStart, false},
+		{"a: 1\n", EventMappingStart, true},
+		{"!!seq [a]\n", EventSequenceStart, false},
+		{"[a]\n", EventSequenceStart, true},
+		{"&x [a]\n", EventSequenceStart, true},
+	}
+	for _, tt := range tests {
+		event := firstOfType(tt.typ)(parseEvents(t, tt.src))
+		if event.Implicit != tt.implicit {
+			t.Errorf("%q: %v is implicit %v, want %v", tt.src, tt.typ, event.Implicit, tt.implicit)
+		}
+
+		// The emitter writes the tag only of a collection that is not
+		// implicit
+		out := emitEvents(t, parseEvents(t, tt.src), EmitterOptions{Preserve: true})
+		if tagged := strings.Contains(out, "!"); tagged == tt.implicit {
+			t.Errorf("%q emitted as %q", tt.src, out)
+		}
+	}
+}
+
+func TestResetDropsHook(t *testing.T) {
+	p, err := NewParserFromString("a: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	calls := 0
+	p.OnEvent(func(*Event) { calls++ })
+	if err := p.Reset(strings.NewReader("b: 2\n")); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		event, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+	}
+	if calls != 0 {
+		t.Errorf("hook registered before Reset was called %d times", calls)
+	}
+}
+
+func TestValueBytes(t *testing.T) {
+	events := parseEvents(t, "a: first\nb: 'second'\nc: |\n  third\n")
+	var values [][]byte
+	for _, event := range events {
+		if event.Type == EventScalar {
+			values = append(values, event.ValueBytes())
+		}
+	}
+	// The bytes of each event stay valid after the parser has moved on
+	k := 0
+	for _, event := range events {
+		if event.Type != EventScalar {
+			continue
+		}
+		if string(values[k]) != event.Value {
+			t.Errorf("value bytes %q of %q changed after later events", values[k], event.Value)
+		}
+		k++
+	}
+
+	event := findScalarEvent(t, events, "first")
+	event.Value = "changed"
+	if got := string(event.ValueBytes()); got != "changed" {
+		t.Errorf("value bytes are %q after changing Value, want %q", got, "changed")
+	}
+	if got := string(NewScalar("built", 0).ValueBytes()); got != "built" {
+		t.Errorf("value bytes of a constructed scalar are %q", got)
+	}
+}
+
+func TestValueBytesNextInto(t *testing.T) {
+	p, err := NewParserFromString("- one\n- one\n- three\n- 'one'\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	var event Event
+	for {
+		ok, err := p.NextInto(&event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		// Each call leaves the bytes of the event it read, including when
+		// it keeps a repeated Value
+		if got := string(event.ValueBytes()); event.Type == EventScalar && got != event.Value {
+			t.Errorf("value bytes are %q, want %q", got, event.Value)
+		}
+	}
+}
+
+func TestCommentBuffers(t *testing.T) {
+	const entries = 50
+	var b strings.Builder
+	for i := 0; i < entries; i++ {
+		fmt.Fprintf(&b, "# head %d\nkey%d: value%d\n", i, i, i)
+	}
+	p, err := NewParserFromString(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// Events from Next keep their own comments while NextInto reuses one
+	// buffer for the events after them
+	var kept []*Event
+	for len(kept) < 10 {
+		event, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Type == EventScalar && len(event.HeadComment) > 0 {
+			kept = append(kept, event)
+		}
+	}
+	var event Event
+	into := len(kept)
+	for {
+		ok, err := p.NextInto(&event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		if event.Type == EventScalar && len(event.HeadComment) > 0 {
+			if want := fmt.Sprintf("# head %d", into); string(event.HeadComment) != want {
+				t.Errorf("NextInto head comment is %q, want %q", event.HeadComment, want)
+			}
+			into++
+		}
+	}
+	if into != entries {
+		t.Errorf("NextInto read %d commented keys, want %d", into, entries)
+	}
+	for i, event := range kept {
+		if want := fmt.Sprintf("# head %d", i); string(event.HeadComment) != want {
+			t.Errorf("head comment from Next is %q after later calls, want %q", event.HeadComment, want)
+		}
+	}
+}
+
+// encodeUTF16 encodes s as UTF-16, big or little endian, with an optional
+// byte order mark
+func encodeUTF16(s string, bigEndian, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xfeff}, units...)
+	}
+	b := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return b
+}
+
+func TestUTF16(t *testing.T) {
+	const src = "key: café\nlist: [ü, \"😀\"]\n"
+	tests := []struct {
+		name string
+		src  []byte
+		opts []ParserOption
+	}{
+		{"little endian with bom", encodeUTF16(src, false, true), nil},
+		{"big endian with bom", encodeUTF16(src, true, true), nil},
+		{"forced little endian", encodeUTF16(src, false, false), []ParserOption{WithEncoding(EncodingUTF16LE)}},
+		{"forced big endian", encodeUTF16(src, true, false), []ParserOption{WithEncoding(EncodingUTF16BE)}},
+		{"forced with bom", encodeUTF16(src, true, true), []ParserOption{WithEncoding(EncodingUTF16BE)}},
+	}
+	want := []struct {
+		value        string
+		line, column int
+	}{
+		{"key", 0, 0}, {"café", 0, 5}, {"list", 1, 0}, {"ü", 1, 7}, {"😀", 1, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, read := range map[string]func() (*Parser, error){
+				"bytes":  func() (*Parser, error) { return NewParserFromBytes(tt.src, tt.opts...) },
+				"reader": func() (*Parser, error) { return NewParser(strings.NewReader(string(tt.src)), tt.opts...) },
+			} {
+				p, err := read()
+				if err != nil {
+					t.Fatal(err)
+				}
+				var scalars []*Event
+				for {
+					event, err := p.Next()
+					if err != nil {
+						t.Fatalf("%s: %v", name, err)
+					}
+					if event == nil {
+						break
+					}
+					if event.Type == EventScalar {
+						scalars = append(scalars, event)
+					}
+				}
+				p.Close()
+				if len(scalars) != len(want) {
+					t.Fatalf("%s: got %d scalars, want %d", name, len(scalars), len(want))
+				}
+				for k, w := range want {
+					e := scalars[k]
+					if e.Value != w.value || e.StartMark.Line != w.line || e.StartMark.Column != w.column {
+						t.Errorf("%s: scalar %q at line %d, column %d, want %q at line %d, column %d",
+							name, e.Value, e.StartMark.Line, e.StartMark.Column, w.value, w.line, w.column)
+					}
+				}
+			}
+		})
+	}
+}
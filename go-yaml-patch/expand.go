@@ -0,0 +1,209 @@
+package yaml
+
+import "strings"
+
+// ExpandOptions controls how ExpandAliasesWithOptions replaces aliases
+type ExpandOptions struct {
+	// MaxExpansions is the number of nodes the copies may add to a
+	// document before an ExpansionLimitError is returned, guarding against
+	// "billion laughs" documents. Zero selects the default of 1000000.
+	MaxExpansions int
+	// StripAnchors removes the anchor names that are left once no alias
+	// refers to them
+	StripAnchors bool
+}
+
+// DefaultMaxExpansions is the ExpandOptions.MaxExpansions used when it is
+// zero
+const DefaultMaxExpansions = 1000000
+
+// ExpandAliases returns a copy of the given events with every alias
+// replaced by a copy of the events of the node it refers to
+func ExpandAliases(events []*Event) ([]*Event, error) {
+	return ExpandAliasesWithOptions(events, ExpandOptions{})
+}
+
+// StripAnchors returns a copy of the given events with every alias
+// expanded and every anchor removed, for output to formats such as JSON
+// that have no references
+func StripAnchors(events []*Event) ([]*Event, error) {
+	return ExpandAliasesWithOptions(events, ExpandOptions{StripAnchors: true})
+}
+
+// ExpandAliasesWithOptions returns a copy of the given events with every
+// alias replaced by a copy of the events of the node it refers to, using
+// the given options. An alias refers to the latest node with its anchor
+// earlier in the same document, as it does when parsing. The copies have
+// no anchors, take the comments of the alias they replace, and keep the
+// marks of the anchored node while their Depth and Path are those of the
+// alias.
+//
+// An alias to a collection that encloses it is a CycleError, and an alias
+// to an anchor not defined before it is an UndefinedAnchorError. Events
+// that are not changed are shared with the input rather than copied.
+func ExpandAliasesWithOptions(events []*Event, options ExpandOptions) ([]*Event, error) {
+	x := expander{
+		events:  events,
+		targets: make(map[int]int),
+		limit:   options.MaxExpansions,
+		strip:   options.StripAnchors,
+	}
+	if x.limit == 0 {
+		x.limit = DefaultMaxExpansions
+	}
+	if err := x.resolve(); err != nil {
+		return nil, err
+	}
+
+	out := make([]*Event, 0, len(events))
+	for i, event := range events {
+		switch {
+		case event.Type == EventDocumentStart:
+			x.expansions = 0
+		case event.Type == EventAlias:
+			copies, err := x.copyNode(x.targets[i], event)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, copies...)
+			continue
+		case event.Anchor != "" && x.strip:
+			event = event.Clone()
+			event.Anchor = ""
+		}
+		out = append(out, event)
+	}
+	return out, nil
+}
+
+// expander replaces the aliases of a stream with copies
+type expander struct {
+	events []*Event
+	// targets maps the index of each alias to the index of the node it
+	// refers to
+	targets    map[int]int
+	limit      int
+	strip      bool
+	expansions int // nodes added to the current document so far
+}
+
+// resolve finds the node each alias refers to
+func (x *expander) resolve() error {
+	anchors := make(map[string]int)
+	for i, event := range x.events {
+		switch {
+		case event.Type == EventDocumentStart:
+			anchors = make(map[string]int)
+		case event.Type == EventAlias:
+			target, ok := anchors[event.Anchor]
+			if !ok {
+				return &UndefinedAnchorError{
+					Anchor: event.Anchor,
+					Path:   event.Path,
+					Mark:   event.StartMark,
+				}
+			}
+			end, err := skipNode(x.events, target)
+			if err != nil {
+				return err
+			}
+			if end > i {
+				return &CycleError{
+					Anchor:     event.Anchor,
+					Path:       event.Path,
+					AnchorMark: x.events[target].StartMark,
+					Mark:       event.StartMark,
+				}
+			}
+			x.targets[i] = target
+		case event.Anchor != "":
+			anchors[event.Anchor] = i
+		}
+	}
+	return nil
+}
+
+// copyNode returns a copy of the events of the node at events[target] to
+// stand in place of the given alias, with the aliases within it expanded
+func (x *expander) copyNode(target int, alias *Event) ([]*Event, error) {
+	end, err := skipNode(x.events, target)
+	if err != nil {
+		return nil, err
+	}
+	base := x.events[target]
+	var out []*Event
+	for i := target; i < end; i++ {
+		event := x.events[i]
+		copied := event.Clone()
+		copied.Depth += alias.Depth - base.Depth
+		copied.Path = alias.Path + strings.TrimPrefix(event.Path, base.Path)
+		if event.Type == EventAlias {
+			copies, err := x.copyNode(x.targets[i], copied)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, copies...)
+			continue
+		}
+		copied.Anchor = ""
+		if event.Type == EventScalar || event.Type.IsCollectionStart() {
+			if x.expansions++; x.expansions > x.limit {
+				return nil, &ExpansionLimitError{
+					Limit:  x.limit,
+					Anchor: alias.Anchor,
+					Path:   alias.Path,
+					Mark:   alias.StartMark,
+				}
+			}
+		}
+		out = append(out, copied)
+	}
+
+	first := out[0]
+	first.HeadComment = alias.HeadComment
+	first.LineComment = alias.LineComment
+	first.FootComment = alias.FootComment
+	first.TailComment = alias.TailComment
+	return out, nil
+}
+
+// FindUnusedAnchors returns the anchors in the given events that no alias
+// refers to, in the order they are defined. An anchor that is redefined is
+// reported for each definition that no alias refers to before the next, as
+// aliases after it refer to the later node.
+func FindUnusedAnchors(events []*Event) []string {
+	var unused []string
+	// defs holds the anchors defined in the document and whether an alias
+	// refers to each, and latest the index in defs of each name's latest
+	// definition
+	type definition struct {
+		name string
+		used bool
+	}
+	var defs []definition
+	latest := make(map[string]int)
+	flush := func() {
+		for _, def := range defs {
+			if !def.used {
+				unused = append(unused, def.name)
+			}
+		}
+		defs = defs[:0]
+		latest = make(map[string]int)
+	}
+	for _, event := range events {
+		switch {
+		case event.Type == EventDocumentStart:
+			flush()
+		case event.Type == EventAlias:
+			if i, ok := latest[event.Anchor]; ok {
+				defs[i].used = true
+			}
+		case event.Anchor != "":
+			latest[event.Anchor] = len(defs)
+			defs = append(defs, definition{name: event.Anchor})
+		}
+	}
+	flush()
+	return unused
+}
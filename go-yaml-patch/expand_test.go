@@ -0,0 +1,123 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandAliases(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"mapping", "a: &x {k: [1, 2]}\nb: *x\n", "a: {k: [1, 2]}\nb: {k: [1, 2]}\n"},
+		{"nested aliases", "a: &x 1\nb: &y [*x, *x]\nc: *y\n", "a: 1\nb: [1, 1]\nc: [1, 1]\n"},
+		{"redefined anchor", "a: &x 1\nb: *x\nc: &x 2\nd: *x\n", "a: 1\nb: 1\nc: 2\nd: 2\n"},
+		{"alias key", "a: &k key\n*k : v\n", "a: key\nkey: v\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandAliases(parseEvents(t, tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, event := range got {
+				if event.Type == EventAlias {
+					t.Fatalf("alias *%s left at %q", event.Anchor, event.Path)
+				}
+			}
+			ops, err := Diff(parseEvents(t, tt.want), got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ops) != 0 {
+				t.Errorf("expanded document differs from %q at %q", tt.want, ops[0].Path)
+			}
+		})
+	}
+}
+
+func TestExpandAliasesCopies(t *testing.T) {
+	got, err := ExpandAliases(parseEvents(t, "a: &x {k: 1}\nb: *x\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var anchors []string
+	var copied *Event
+	for _, event := range got {
+		if event.Anchor != "" {
+			anchors = append(anchors, event.Path)
+		}
+		if event.Type == EventScalar && event.Value == "k" && event.Path == "/b/k" {
+			copied = event
+		}
+	}
+	if len(anchors) != 1 || anchors[0] != "/a" {
+		t.Errorf("anchors are at %q, want only /a", anchors)
+	}
+	if copied == nil || copied.Depth != 2 || copied.StartMark.Line != 0 {
+		t.Errorf("copied key is %+v, want depth 2 at /b/k with the marks of its definition", copied)
+	}
+
+	stripped, err := StripAnchors(parseEvents(t, "a: &x {k: &y 1}\nb: *x\nc: &z 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range stripped {
+		if event.Anchor != "" {
+			t.Errorf("anchor &%s left at %q", event.Anchor, event.Path)
+		}
+	}
+}
+
+func TestExpandAliasesErrors(t *testing.T) {
+	_, err := ExpandAliases(parseEvents(t, "a: *x\nb: &x 1\n"))
+	if e, ok := err.(*UndefinedAnchorError); !ok || e.Anchor != "x" {
+		t.Errorf("got error %v for a forward alias, want an UndefinedAnchorError", err)
+	}
+	_, err = ExpandAliases(parseEvents(t, "--- &x 1\n--- *x\n"))
+	if _, ok := err.(*UndefinedAnchorError); !ok {
+		t.Errorf("got error %v for an alias to a previous document, want an UndefinedAnchorError", err)
+	}
+	_, err = ExpandAliases(parseEvents(t, "a: &x\n  b: *x\n"))
+	if _, ok := err.(*CycleError); !ok {
+		t.Errorf("got error %v for a cycle, want a CycleError", err)
+	}
+
+	lol := parseEvents(t, laughs(8))
+	_, err = ExpandAliasesWithOptions(lol, ExpandOptions{MaxExpansions: 1000})
+	if e, ok := err.(*ExpansionLimitError); !ok || e.Limit != 1000 {
+		t.Errorf("got error %v for billion laughs, want an ExpansionLimitError", err)
+	}
+	_, err = ExpandAliases(lol)
+	if e, ok := err.(*ExpansionLimitError); !ok || e.Limit != DefaultMaxExpansions {
+		t.Errorf("got error %v for billion laughs, want an ExpansionLimitError at the default limit", err)
+	}
+	if _, err := ExpandAliasesWithOptions(parseEvents(t, laughs(1)), ExpandOptions{MaxExpansions: 110}); err != nil {
+		t.Errorf("one level, adding 110 nodes, is over a limit of 110: %v", err)
+	}
+}
+
+func TestFindUnusedAnchors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"none", "a: 1\n", ""},
+		{"all used", "a: &x 1\nb: &y [*x]\nc: *y\n", ""},
+		{"unused", "a: &x 1\nb: &y 2\nc: &z 3\nd: *y\n", "x z"},
+		{"redefined", "a: &x 1\nb: &x 2\nc: *x\n", "x"},
+		{"used before redefinition", "a: &x 1\nb: *x\nc: &x 2\n", "x"},
+		{"per document", "--- &x 1\n--- [&x 2, *x]\n", "x"},
+		{"collections", "a: &m {k: v}\nb: &s [1]\n", "m s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(FindUnusedAnchors(parseEvents(t, tt.src)), " "); got != tt.want {
+				t.Errorf("unused anchors are %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package yaml
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// JSONOptions controls how EventsToJSON writes a YAML stream
+type JSONOptions struct {
+	// DocumentArray writes the documents of the stream as the elements of
+	// a single JSON array. By default each document is written as its own
+	// JSON value on its own line, which is a single value for a
+	// single-document stream.
+	DocumentArray bool
+	// MaxExpansions is the number of nodes the copies of aliased nodes may
+	// add to a document before an ExpansionLimitError is returned, as for
+	// ExpandOptions. Zero selects DefaultMaxExpansions.
+	MaxExpansions int
+}
+
+// EventsToJSON writes the documents of the given event stream to w as
+// JSON, one value per line
+func EventsToJSON(events []*Event, w io.Writer) error {
+	return EventsToJSONWithOptions(events, w, JSONOptions{})
+}
+
+// EventsToJSONWithOptions writes the documents of the given event stream
+// to w as JSON using the given options.
+//
+// Plain scalars are resolved with the core schema, so null, booleans and
+// numbers become their JSON counterparts; all other scalars, including
+// quoted ones and infinite or NaN floats, become strings. Aliases are
+// expanded into copies of their anchored nodes, and an alias to a
+// collection that encloses it is an error. Mapping keys are written as
+// the string value of the key scalar, which may be an alias to an anchored
+// key.
+func EventsToJSONWithOptions(events []*Event, w io.Writer, options JSONOptions) error {
+	jw := jsonWriter{events: events, w: bufio.NewWriter(w), limit: options.MaxExpansions}
+	if jw.limit == 0 {
+		jw.limit = DefaultMaxExpansions
+	}
+	if options.DocumentArray {
+		jw.w.WriteByte('[')
+	}
+	docs := 0
+	for i := 0; i < len(events); {
+		if events[i].Type != EventDocumentStart {
+			i++
+			continue
+		}
+		jw.anchors = nil
+		jw.expansions = 0
+		if docs > 0 && options.DocumentArray {
+			jw.w.WriteByte(',')
+		}
+		next, err := jw.node(i + 1)
+		if err != nil {
+			return err
+		}
+		if !options.DocumentArray {
+			jw.w.WriteByte('\n')
+		}
+		docs++
+		i = next
+	}
+	if options.DocumentArray {
+		jw.w.WriteString("]\n")
+	}
+	return jw.w.Flush()
+}
+
+// jsonWriter writes the nodes of an event slice as JSON
+type jsonWriter struct {
+	events []*Event
+	w      *bufio.Writer
+
+	// anchors maps the anchors of the current document to the index of
+	// the event that starts the anchored node
+	anchors map[string]int
+	// open holds the start indexes of the collections being written
+	open []int
+	// expanding counts the aliases being expanded, the outermost of which
+	// is expansion, and expansions the nodes their copies have added to
+	// the document, up to limit
+	expanding  int
+	expansion  *Event
+	expansions int
+	limit      int
+}
+
+// node writes the node starting at events[i] and returns the index of the
+// event following it
+func (jw *jsonWriter) node(i int) (int, error) {
+	if i >= len(jw.events) {
+		return i, fmt.Errorf("unexpected end of events")
+	}
+	event := jw.events[i]
+	if event.Type != EventAlias {
+		jw.addAnchor(i)
+		if err := jw.count(); err != nil {
+			return i, err
+		}
+	}
+
+	var opening, closing byte
+	switch event.Type {
+	case EventScalar:
+		jw.scalar(event)
+		return i + 1, nil
+	case EventAlias:
+		return i + 1, jw.alias(event)
+	case EventSequenceStart:
+		opening, closing = '[', ']'
+	case EventMappingStart:
+		opening, closing = '{', '}'
+	default:
+		return i, fmt.Errorf("unexpected %v event, expected a node", event.Type)
+	}
+
+	jw.open = append(jw.open, i)
+	jw.w.WriteByte(opening)
+	mapping := event.Type == EventMappingStart
+	var err error
+	for i, n := i+1, 0; ; n++ {
+		if i >= len(jw.events) {
+			return i, fmt.Errorf("unexpected end of events")
+		}
+		if jw.events[i].Type.IsCollectionEnd() {
+			jw.w.WriteByte(closing)
+			jw.open = jw.open[:len(jw.open)-1]
+			return i + 1, nil
+		}
+		if n > 0 {
+			jw.w.WriteByte(',')
+		}
+		if mapping {
+			key, err := jw.key(i)
+			if err != nil {
+				return i, err
+			}
+			jw.writeString(key)
+			jw.w.WriteByte(':')
+			i++
+		}
+		if i, err = jw.node(i); err != nil {
+			return i, err
+		}
+	}
+}
+
+// alias writes a copy of the node the given alias refers to, failing if
+// that node encloses the alias
+func (jw *jsonWriter) alias(event *Event) error {
+	start, ok := jw.anchors[event.Anchor]
+	if !ok {
+		return fmt.Errorf("%s: unknown anchor %q referenced",
+			position(event.StartMark), event.Anchor)
+	}
+	for _, open := range jw.open {
+		if open == start {
+			return &CycleError{
+				Anchor:     event.Anchor,
+				Path:       event.Path,
+				AnchorMark: jw.events[start].StartMark,
+				Mark:       event.StartMark,
+			}
+		}
+	}
+
+	if jw.expanding == 0 {
+		jw.expansion = event
+	}
+	jw.expanding++
+	_, err := jw.node(start)
+	jw.expanding--
+	return err
+}
+
+// addAnchor records the anchor of the node starting at events[i], unless
+// the node is a copy being written for an alias
+func (jw *jsonWriter) addAnchor(i int) {
+	event := jw.events[i]
+	if event.Anchor == "" || jw.expanding > 0 {
+		return
+	}
+	if jw.anchors == nil {
+		jw.anchors = make(map[string]int)
+	}
+	jw.anchors[event.Anchor] = i
+}
+
+// count accounts for a node written as part of a copy, returning an
+// ExpansionLimitError once the copies have added more than the limit
+func (jw *jsonWriter) count() error {
+	if jw.expanding == 0 {
+		return nil
+	}
+	if jw.expansions++; jw.expansions > jw.limit {
+		return &ExpansionLimitError{
+			Limit:  jw.limit,
+			Anchor: jw.expansion.Anchor,
+			Path:   jw.expansion.Path,
+			Mark:   jw.expansion.StartMark,
+		}
+	}
+	return nil
+}
+
+// key returns the JSON object key for the mapping key event at events[i]
+func (jw *jsonWriter) key(i int) (string, error) {
+	event := jw.events[i]
+	if event.Type == EventAlias {
+		if start, ok := jw.anchors[event.Anchor]; ok {
+			event = jw.events[start]
+		}
+	} else {
+		jw.addAnchor(i)
+		if err := jw.count(); err != nil {
+			return "", err
+		}
+	}
+	if event.Type != EventScalar {
+		return "", fmt.Errorf("%s: cannot write a %v mapping key as JSON",
+			position(event.StartMark), event.Type)
+	}
+	return event.Value, nil
+}
+
+// scalar writes a scalar as the JSON value its core schema tag implies
+func (jw *jsonWriter) scalar(event *Event) {
+	switch event.ResolvedTag() {
+	case yaml_NULL_TAG:
+		jw.w.WriteString("null")
+		return
+	case yaml_BOOL_TAG:
+		if b, ok := event.AsBool(); ok {
+			jw.w.WriteString(strconv.FormatBool(b))
+			return
+		}
+	case yaml_INT_TAG:
+		if i, ok := event.AsInt(); ok {
+			jw.w.WriteString(strconv.FormatInt(i, 10))
+			return
+		}
+		if u, ok := event.AsUint(); ok {
+			jw.w.WriteString(strconv.FormatUint(u, 10))
+			return
+		}
+	case yaml_FLOAT_TAG:
+		if f, ok := event.AsFloat(); ok && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			jw.w.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+			return
+		}
+	}
+	jw.writeString(event.Value)
+}
+
+// writeString writes s as a JSON string
+func (jw *jsonWriter) writeString(s string) {
+	b, _ := json.Marshal(s)
+	jw.w.Write(b)
+}
@@ -0,0 +1,54 @@
+package yaml
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEventsToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"scalars", "s: text\ni: 12\nf: 1.5\nb: true\nn: ~\nq: '12'\n", `{"s":"text","i":12,"f":1.5,"b":true,"n":null,"q":"12"}`},
+		{"sequence", "- 1\n- [a, {b: c}]\n", `[1,["a",{"b":"c"}]]`},
+		{"alias", "a: &x {k: v}\nb: *x\n", `{"a":{"k":"v"},"b":{"k":"v"}}`},
+		{"anchored key", "&k key: v\nother: *k\n", `{"key":"v","other":"key"}`},
+		{"alias key", "a: &k name\n*k : 2\n", `{"a":"name","name":2}`},
+		{"anchor in a copy", "a: &x [&y 1]\nb: *x\nc: *y\n", `{"a":[1],"b":[1],"c":1}`},
+		{"documents", "--- 1\n--- [2]\n", "1\n[2]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := EventsToJSON(parseEvents(t, tt.src), &b); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(bytes.TrimSpace(b.Bytes())); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventsToJSONErrors(t *testing.T) {
+	var b bytes.Buffer
+	err := EventsToJSON(parseEvents(t, "a: &a\n  b: *a\n"), &b)
+	if _, ok := err.(*CycleError); !ok {
+		t.Errorf("got error %v for a cycle, want a CycleError", err)
+	}
+
+	err = EventsToJSONWithOptions(parseEvents(t, laughs(8)), &b, JSONOptions{MaxExpansions: 1000})
+	e, ok := err.(*ExpansionLimitError)
+	if !ok {
+		t.Fatalf("got error %v for billion laughs, want an ExpansionLimitError", err)
+	}
+	if e.Anchor != "l1" || e.Path != "/l2/8" {
+		t.Errorf("limit passed by *%s at %q, want *l1 at %q", e.Anchor, e.Path, "/l2/8")
+	}
+
+	if err := EventsToJSON(parseEvents(t, laughs(3)), &b); err != nil {
+		t.Errorf("three levels are within the default limit: %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PatchOp is one operation of a JSON Patch (RFC 6902). Op is one of "add",
+// "remove", "replace", "move", "copy" and "test". Path and From are JSON
+// Pointers (RFC 6901), and Value holds the events of the node to add,
+// replace with or test against, such as those NewScalar returns.
+type PatchOp struct {
+	Op    string
+	Path  string
+	From  string
+	Value []*Event
+}
+
+// ApplyJSONPatch applies the given operations in order to the first
+// document in events and returns the patched events. The events of the
+// nodes the operations do not touch are kept, so the rest of the document
+// keeps its comments and styles. Keys added to a mapping go after its
+// existing entries, with a style left to the emitter. A "test" operation
+// compares nodes as Diff does, so scalars match by value and resolved tag
+// whatever their style. Any failed operation fails the whole patch, as does
+// one that leaves an alias without an anchor before it.
+func ApplyJSONPatch(events []*Event, patch []PatchOp) ([]*Event, error) {
+	out := append([]*Event(nil), events...)
+	for n, op := range patch {
+		var err error
+		if out, err = applyPatchOp(out, op); err == nil {
+			err = checkAnchors(out)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("patch operation %d (%s %q): %v", n, op.Op, op.Path, err)
+		}
+	}
+	return out, nil
+}
+
+func applyPatchOp(events []*Event, op PatchOp) ([]*Event, error) {
+	switch op.Op {
+	case "add":
+		return patchAdd(events, op.Path, op.Value)
+	case "remove":
+		return patchRemove(events, op.Path)
+	case "replace":
+		start, end, err := patchTarget(events, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return splice(events, start, end, op.Value)
+	case "move":
+		if op.Path != op.From && strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move a node into itself")
+		}
+		start, end, err := patchTarget(events, op.From)
+		if err != nil {
+			return nil, err
+		}
+		value := append([]*Event(nil), events[start:end]...)
+		if events, err = patchRemove(events, op.From); err != nil {
+			return nil, err
+		}
+		return patchAdd(events, op.Path, value)
+	case "copy":
+		start, end, err := patchTarget(events, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(events, op.Path, events[start:end])
+	case "test":
+		start, end, err := patchTarget(events, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		diff, err := Diff(events[start:end], op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if len(diff) > 0 {
+			return nil, fmt.Errorf("test failed")
+		}
+		return events, nil
+	default:
+		return nil, fmt.Errorf("unknown operation")
+	}
+}
+
+// checkNode returns an error unless the given events form a single node
+func checkNode(node []*Event) error {
+	if len(node) == 0 {
+		return fmt.Errorf("value is not a single node")
+	}
+	if end, err := skipNode(node, 0); err != nil || end != len(node) {
+		return fmt.Errorf("value is not a single node")
+	}
+	return nil
+}
+
+// splice replaces events[start:end] with the given node
+func splice(events []*Event, start, end int, node []*Event) ([]*Event, error) {
+	if err := checkNode(node); err != nil {
+		return nil, err
+	}
+	out := make([]*Event, 0, len(events)-(end-start)+len(node))
+	out = append(out, events[:start]...)
+	out = append(out, node...)
+	return append(out, events[end:]...), nil
+}
+
+// pathUnescaper unescapes a JSON Pointer reference token
+var pathUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// splitPointer splits a JSON Pointer into its unescaped reference tokens
+func splitPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("path %q does not start with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = pathUnescaper.Replace(token)
+	}
+	return tokens, nil
+}
+
+// patchTarget returns the bounds of the node at the given path
+func patchTarget(events []*Event, pointer string) (start, end int, err error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return 0, 0, err
+	}
+	start, end, err = rootNode(events)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start < 0 {
+		return 0, 0, fmt.Errorf("no document to patch")
+	}
+	for _, token := range tokens {
+		child, err := findChild(events, start, token)
+		if err != nil {
+			return 0, 0, err
+		}
+		if child.value < 0 {
+			return 0, 0, fmt.Errorf("path %q not found", pointer)
+		}
+		start, end = child.value, child.end
+	}
+	return start, end, nil
+}
+
+// childRef locates a child of a collection: its key, if any, and its
+// value, which is -1 if there is no such child. For a sequence index at or
+// past its end, key is where an item would be inserted.
+type childRef struct {
+	key, value, end int
+}
+
+// findChild finds the child that the given reference token names in the
+// collection starting at events[start]
+func findChild(events []*Event, start int, token string) (childRef, error) {
+	switch events[start].Type {
+	case EventMappingStart:
+		found := childRef{value: -1}
+		i := start + 1
+		for i < len(events) && !events[i].Type.IsCollectionEnd() {
+			value, err := skipNode(events, i)
+			if err != nil {
+				return found, err
+			}
+			end, err := skipNode(events, value)
+			if err != nil {
+				return found, err
+			}
+			// The last of repeated keys wins, as when decoding
+			if key := events[i]; key.Type == EventScalar && key.Value == token {
+				found = childRef{key: i, value: value, end: end}
+			}
+			i = end
+		}
+		if found.value < 0 {
+			found.key = i
+		}
+		return found, nil
+	case EventSequenceStart:
+		index := -1
+		if token != "-" {
+			n, err := strconv.Atoi(token)
+			if err != nil || n < 0 || token != strconv.Itoa(n) {
+				return childRef{}, fmt.Errorf("invalid sequence index %q", token)
+			}
+			index = n
+		}
+		i := start + 1
+		for n := 0; i < len(events) && !events[i].Type.IsCollectionEnd(); n++ {
+			end, err := skipNode(events, i)
+			if err != nil {
+				return childRef{}, err
+			}
+			if n == index {
+				return childRef{key: i, value: i, end: end}, nil
+			}
+			i = end
+		}
+		return childRef{key: i, value: -1}, nil
+	default:
+		return childRef{}, fmt.Errorf("cannot find %q in a %v node", token, events[start].Type)
+	}
+}
+
+// patchAdd adds the given node at the path, replacing an existing mapping
+// value or inserting a sequence item
+func patchAdd(events []*Event, pointer string, value []*Event) ([]*Event, error) {
+	if pointer == "" {
+		start, end, err := patchTarget(events, "")
+		if err != nil {
+			return nil, err
+		}
+		return splice(events, start, end, value)
+	}
+	parent, token, err := patchParent(events, pointer)
+	if err != nil {
+		return nil, err
+	}
+	child, err := findChild(events, parent, token)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case events[parent].Type == EventMappingStart && child.value >= 0:
+		return splice(events, child.value, child.end, value)
+	case events[parent].Type == EventMappingStart:
+		return insert(events, child.key, NewScalar(token, StyleAny), value)
+	case child.value >= 0 || token == "-":
+		return insert(events, child.key, nil, value)
+	}
+	// An index equal to the length appends, anything past it is an error
+	if n, _ := strconv.Atoi(token); n != countItems(events, parent) {
+		return nil, fmt.Errorf("sequence index %d out of range", n)
+	}
+	return insert(events, child.key, nil, value)
+}
+
+// insert inserts the given node, after the given mapping key if not nil,
+// before events[at]
+func insert(events []*Event, at int, key *Event, node []*Event) ([]*Event, error) {
+	if err := checkNode(node); err != nil {
+		return nil, err
+	}
+	entry := node
+	if key != nil {
+		entry = append([]*Event{key}, node...)
+	}
+	out := make([]*Event, 0, len(events)+len(entry))
+	out = append(out, events[:at]...)
+	out = append(out, entry...)
+	return append(out, events[at:]...), nil
+}
+
+// patchRemove removes the node at the path, with its key in a mapping
+func patchRemove(events []*Event, pointer string) ([]*Event, error) {
+	if pointer == "" {
+		return nil, fmt.Errorf("cannot remove the root node")
+	}
+	parent, token, err := patchParent(events, pointer)
+	if err != nil {
+		return nil, err
+	}
+	child, err := findChild(events, parent, token)
+	if err != nil {
+		return nil, err
+	}
+	if child.value < 0 {
+		return nil, fmt.Errorf("path %q not found", pointer)
+	}
+	return append(events[:child.key:child.key], events[child.end:]...), nil
+}
+
+// patchParent returns the start of the collection holding the node at the
+// given path, which must not be the root, and the last reference token of
+// the path, which names the node within it
+func patchParent(events []*Event, pointer string) (parent int, token string, err error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return 0, "", err
+	}
+	parent, _, err = patchTarget(events, pointer[:strings.LastIndexByte(pointer, '/')])
+	if err != nil {
+		return 0, "", err
+	}
+	return parent, tokens[len(tokens)-1], nil
+}
+
+// countItems returns the number of items in the sequence starting at
+// events[start]
+func countItems(events []*Event, start int) int {
+	n := 0
+	for i := start + 1; i < len(events) && !events[i].Type.IsCollectionEnd(); n++ {
+		end, err := skipNode(events, i)
+		if err != nil {
+			break
+		}
+		i = end
+	}
+	return n
+}
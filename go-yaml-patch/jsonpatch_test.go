@@ -0,0 +1,44 @@
+package yaml
+
+import "testing"
+
+func TestApplyJSONPatch(t *testing.T) {
+	patch := []PatchOp{
+		{Op: "add", Path: "/d", Value: []*Event{NewScalar("4", 0)}},
+		{Op: "remove", Path: "/a"},
+		{Op: "replace", Path: "/b/0", Value: []*Event{NewScalar("x", 0)}},
+		{Op: "move", From: "/c", Path: "/e"},
+		{Op: "copy", From: "/d", Path: "/b/-"},
+		{Op: "test", Path: "/e", Value: []*Event{NewScalar("3", 0)}},
+	}
+	got, err := ApplyJSONPatch(parseEvents(t, "a: 1\nb: [2]\nc: 3\n"), patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, err := Diff(parseEvents(t, "b: [x, 4]\nd: 4\ne: 3\n"), got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 0 {
+		t.Errorf("patched document differs at %q", ops[0].Path)
+	}
+}
+
+func TestApplyJSONPatchAnchors(t *testing.T) {
+	src := "a: &x 1\nb: *x\n"
+	tests := []struct {
+		name string
+		op   PatchOp
+	}{
+		{"remove the anchor", PatchOp{Op: "remove", Path: "/a"}},
+		{"move the anchor after the alias", PatchOp{Op: "move", From: "/a", Path: "/c"}},
+		{"copy the alias before the anchor", PatchOp{Op: "copy", From: "/b", Path: "/a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ApplyJSONPatch(parseEvents(t, src), []PatchOp{tt.op}); err == nil {
+				t.Error("patch left an alias without its anchor and gave no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mergeState holds what the parser needs to resolve merge keys for the
+// ResolveMergeKeys option
+type mergeState struct {
+	// pending holds resolved events waiting to be returned
+	pending []*Event
+
+	// log holds the resolved events of the current document that belong
+	// to anchored nodes, and spans maps each anchor to the events of its
+	// node within it. The end of a span is -1 while its node is open.
+	log   []*Event
+	spans map[string]mergeSpan
+	// recording holds the anchored collections that are still open
+	recording []mergeSpan
+
+	// maps holds the mappings that are still open
+	maps []mergeFrame
+}
+
+// mergeSpan locates the events of an anchored node in the log, or holds
+// them when the node is in an inline merged mapping, which is not logged
+type mergeSpan struct {
+	anchor     string
+	start, end int
+	events     []*Event
+}
+
+// mergeFrame records an open mapping and the mappings merged into it
+type mergeFrame struct {
+	start  *Event
+	keys   map[string]bool // scalar keys written so far
+	merged [][]*Event      // merged mappings, from MAPPING-START to MAPPING-END
+}
+
+// isMergeKey reports whether the given event is a merge key, a plain "<<"
+// or a scalar with the merge tag
+func isMergeKey(event *Event) bool {
+	if event.Type != EventScalar {
+		return false
+	}
+	if event.Tag == "" {
+		return event.IsPlain() && event.Value == "<<"
+	}
+	return event.fullTag() == yaml_MERGE_TAG
+}
+
+// nextMerged returns the next event of the stream with its merge keys
+// resolved. Each "<<" entry is dropped, and once the mapping ends the
+// entries of the mappings it merged are written before its MAPPING-END,
+// leaving out the keys the mapping already has. Of several merged mappings
+// the earlier ones take precedence.
+func (p *Parser) nextMerged() (*Event, error) {
+	m := &p.merge
+	for len(m.pending) == 0 {
+		event, err := p.parseEvent()
+		if err != nil || event == nil {
+			return nil, err
+		}
+		if err := p.resolveMerge(event); err != nil {
+			p.setErrorOffset(event.StartMark.ByteOffset)
+			return nil, err
+		}
+	}
+	event := m.pending[0]
+	m.pending[0] = nil
+	m.pending = m.pending[1:]
+	return event, nil
+}
+
+// resolveMerge handles the next event from the underlying parser
+func (p *Parser) resolveMerge(event *Event) error {
+	m := &p.merge
+	if event.Type == EventDocumentStart {
+		m.log, m.spans, m.recording, m.maps = nil, nil, nil, nil
+	}
+
+	var top *mergeFrame
+	if len(m.maps) > 0 {
+		top = &m.maps[len(m.maps)-1]
+	}
+	if top != nil && event.IsKey && event.Depth == top.start.Depth+1 {
+		if event.IsMergeKey {
+			merged, err := p.mergeSources()
+			if err != nil {
+				return err
+			}
+			// Inline mappings among the sources push their own frames,
+			// which may have moved the stack
+			top = &m.maps[len(m.maps)-1]
+			top.merged = append(top.merged, merged...)
+			return nil
+		}
+		if event.Type == EventScalar {
+			top.keys[event.Value] = true
+		}
+	}
+
+	switch event.Type {
+	case EventMappingStart:
+		m.maps = append(m.maps, mergeFrame{start: event, keys: make(map[string]bool)})
+	case EventMappingEnd:
+		for _, source := range top.merged {
+			p.writeMerged(top, source)
+		}
+		m.maps = m.maps[:len(m.maps)-1]
+	}
+	p.emitMerged(event)
+	return nil
+}
+
+// mergeSources reads the value of a merge key, an alias to a mapping, a
+// mapping, or a sequence of them, and returns the mappings it merges
+func (p *Parser) mergeSources() ([][]*Event, error) {
+	event, err := p.nextRaw()
+	if err != nil {
+		return nil, err
+	}
+	if event.Type != EventSequenceStart {
+		source, err := p.mergeSource(event)
+		if err != nil {
+			return nil, err
+		}
+		return [][]*Event{source}, nil
+	}
+
+	var sources [][]*Event
+	for {
+		if event, err = p.nextRaw(); err != nil {
+			return nil, err
+		}
+		if event.Type == EventSequenceEnd {
+			return sources, nil
+		}
+		source, err := p.mergeSource(event)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+}
+
+// mergeSource returns the events of the mapping that the given merge value
+// event refers to or starts
+func (p *Parser) mergeSource(event *Event) ([]*Event, error) {
+	switch event.Type {
+	case EventAlias:
+		span, ok := p.merge.spans[event.Anchor]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%s: unknown anchor %q referenced",
+				position(event.StartMark), event.Anchor)
+		case span.end < 0:
+			return nil, fmt.Errorf("%s: cannot merge mapping *%s into itself",
+				position(event.StartMark), event.Anchor)
+		}
+		source := span.events
+		if source == nil {
+			source = p.merge.log[span.start:span.end]
+		}
+		if source[0].Type == EventMappingStart {
+			return source, nil
+		}
+	case EventMappingStart:
+		// An inline mapping is resolved like any other, so its own merge
+		// keys are merged and its anchors recorded, and its events are
+		// then taken back out of the queue and the log, which must not
+		// place them inside an enclosing anchored node
+		m := &p.merge
+		queued, depth := len(m.pending), len(m.maps)
+		logged, recording := len(m.log), m.recording
+		m.recording = nil
+		defer func() { m.recording = recording }()
+		for {
+			if err := p.resolveMerge(event); err != nil {
+				return nil, err
+			}
+			if len(m.maps) == depth {
+				break
+			}
+			var err error
+			if event, err = p.nextRaw(); err != nil {
+				return nil, err
+			}
+		}
+		source := append([]*Event(nil), m.pending[queued:]...)
+		for i := queued; i < len(m.pending); i++ {
+			m.pending[i] = nil
+		}
+		m.pending = m.pending[:queued]
+
+		inline := append([]*Event(nil), m.log[logged:]...)
+		for anchor, span := range m.spans {
+			if span.events == nil && span.start >= logged {
+				span.events = inline[span.start-logged : span.end-logged]
+				span.start = -1
+				m.spans[anchor] = span
+			}
+		}
+		for i := logged; i < len(m.log); i++ {
+			m.log[i] = nil
+		}
+		m.log = m.log[:logged]
+		return source, nil
+	}
+	return nil, fmt.Errorf("%s: map merge requires a mapping or a sequence of mappings as the value",
+		position(event.StartMark))
+}
+
+// nextRaw returns the next event from the underlying parser, failing if
+// the stream ends
+func (p *Parser) nextRaw() (*Event, error) {
+	event, err := p.parseEvent()
+	if err == nil && event == nil {
+		err = fmt.Errorf("unexpected end of events")
+	}
+	return event, err
+}
+
+// writeMerged emits the entries of the given merged mapping whose keys the
+// mapping being closed does not have yet. The copies keep the marks of
+// their definition, while their depth and path are those of their new place.
+func (p *Parser) writeMerged(frame *mergeFrame, source []*Event) {
+	base := source[0]
+	for i := 1; i < len(source)-1; {
+		key := i
+		value := nodeEnd(source, key)
+		end := nodeEnd(source, value)
+		i = end
+
+		if k := source[key]; k.Type == EventScalar {
+			if frame.keys[k.Value] {
+				continue
+			}
+			frame.keys[k.Value] = true
+		}
+		for _, event := range source[key:end] {
+			copied := event.Clone()
+			if copied.Type != EventAlias {
+				copied.Anchor = ""
+			}
+			copied.Depth += frame.start.Depth - base.Depth
+			copied.Path = frame.start.Path + strings.TrimPrefix(event.Path, base.Path)
+			p.emitMerged(copied)
+		}
+	}
+}
+
+// nodeEnd returns the index following the node that starts at events[i]
+func nodeEnd(events []*Event, i int) int {
+	if !events[i].Type.IsCollectionStart() {
+		return i + 1
+	}
+	depth := events[i].Depth
+	for i++; i < len(events); i++ {
+		if events[i].Type.IsCollectionEnd() && events[i].Depth == depth {
+			return i + 1
+		}
+	}
+	return i
+}
+
+// emitMerged queues a resolved event, logging it while it belongs to an
+// anchored node
+func (p *Parser) emitMerged(event *Event) {
+	m := &p.merge
+	m.pending = append(m.pending, event)
+
+	anchored := event.Anchor != "" && event.Type != EventAlias
+	if !anchored && len(m.recording) == 0 {
+		return
+	}
+	if anchored {
+		span := mergeSpan{anchor: event.Anchor, start: len(m.log), end: -1}
+		if event.Type.IsCollectionStart() {
+			m.recording = append(m.recording, span)
+		} else {
+			span.end = span.start + 1
+		}
+		if m.spans == nil {
+			m.spans = make(map[string]mergeSpan)
+		}
+		m.spans[event.Anchor] = span
+	}
+	m.log = append(m.log, event)
+
+	if n := len(m.recording); n > 0 && event.Type.IsCollectionEnd() {
+		span := m.recording[n-1]
+		if start := m.log[span.start]; start.Depth == event.Depth {
+			// The anchor may have been redefined inside the collection,
+			// in which case the later definition stands
+			if m.spans[span.anchor].start == span.start {
+				span.end = len(m.log)
+				m.spans[span.anchor] = span
+			}
+			m.recording = m.recording[:n-1]
+		}
+	}
+}
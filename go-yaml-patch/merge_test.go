@@ -0,0 +1,49 @@
+package yaml
+
+import "testing"
+
+func TestMergeInlineMapping(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			"merge inside an inline mapping",
+			"a: &a {x: 1}\nb:\n  <<: {<<: *a, y: 2}\n  z: 3\n",
+			"a: {x: 1}\nb:\n  z: 3\n  y: 2\n  x: 1\n",
+		},
+		{
+			"merge inside a sequence of inline mappings",
+			"a: &a {x: 1}\nb: {<<: [{<<: *a}, {y: 2}]}\n",
+			"a: {x: 1}\nb: {x: 1, y: 2}\n",
+		},
+		{
+			"anchor inside an inline mapping",
+			"b: {<<: {y: &c {w: 0}}}\nd: {<<: *c}\n",
+			"b: {y: {w: 0}}\nd: {w: 0}\n",
+		},
+		{
+			"inline mapping inside an anchored mapping",
+			"o: &o {<<: {x: 1}, y: 2}\np: {<<: *o}\n",
+			"o: {x: 1, y: 2}\np: {x: 1, y: 2}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseEvents(t, tt.src, WithMergeKeyResolution())
+			for _, event := range got {
+				if event.IsMergeKey {
+					t.Fatalf("merge key left at %q", event.Path)
+				}
+			}
+			ops, err := Diff(parseEvents(t, tt.want), got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ops) != 0 {
+				t.Errorf("resolved %q differs from %q at %q", tt.src, tt.want, ops[0].Path)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package yaml
+
+import (
+	"bytes"
+	"io"
+)
+
+// lineBreaks counts the line breaks of each kind in the input
+type lineBreaks struct {
+	crlf, lf, cr int
+	// pendingCR is set when the bytes counted so far end with a '\r',
+	// which a '\n' at the start of the next bytes would complete
+	pendingCR bool
+	// trailing is set when the bytes counted so far end with a line break
+	trailing bool
+	// encoding is the encoding of the input, detected from its byte order
+	// mark as the underlying parser does unless it is set beforehand
+	encoding yaml_encoding_t
+	// held is the start of the input while it is too short to detect the
+	// encoding, or the first byte of a UTF-16 code unit split between reads
+	held []byte
+}
+
+func (c *lineBreaks) add(b []byte) {
+	if c.encoding == yaml_ANY_ENCODING {
+		if len(c.held)+len(b) < 2 {
+			c.held = append(c.held, b...)
+			return
+		}
+		if len(c.held) > 0 {
+			b = append(c.held, b...)
+			c.held = nil
+		}
+		c.encoding = yaml_UTF8_ENCODING
+		if bytes.HasPrefix(b, []byte{0xff, 0xfe}) {
+			c.encoding = yaml_UTF16LE_ENCODING
+		} else if bytes.HasPrefix(b, []byte{0xfe, 0xff}) {
+			c.encoding = yaml_UTF16BE_ENCODING
+		}
+	}
+	if c.encoding != yaml_UTF16LE_ENCODING && c.encoding != yaml_UTF16BE_ENCODING {
+		for _, ch := range b {
+			c.unit(uint16(ch))
+		}
+		return
+	}
+	if len(c.held) == 1 && len(b) > 0 {
+		c.unit(c.utf16(c.held[0], b[0]))
+		c.held, b = c.held[:0], b[1:]
+	}
+	for ; len(b) >= 2; b = b[2:] {
+		c.unit(c.utf16(b[0], b[1]))
+	}
+	c.held = append(c.held, b...)
+}
+
+// utf16 returns the UTF-16 code unit in the given two bytes
+func (c *lineBreaks) utf16(first, second byte) uint16 {
+	if c.encoding == yaml_UTF16LE_ENCODING {
+		return uint16(first) | uint16(second)<<8
+	}
+	return uint16(first)<<8 | uint16(second)
+}
+
+// unit counts one byte of UTF-8 input or one code unit of UTF-16 input
+func (c *lineBreaks) unit(ch uint16) {
+	switch {
+	case ch == '\n' && c.pendingCR:
+		c.crlf++
+	case ch == '\n':
+		c.lf++
+	case c.pendingCR:
+		c.cr++
+	}
+	c.pendingCR = ch == '\r'
+	c.trailing = ch == '\n' || ch == '\r'
+}
+
+// done returns the counts for the whole input, with the bytes held back
+// from input too short to detect its encoding counted as UTF-8
+func (c lineBreaks) done() lineBreaks {
+	if c.encoding == yaml_ANY_ENCODING && len(c.held) > 0 {
+		held := c.held
+		c.encoding, c.held = yaml_UTF8_ENCODING, nil
+		c.add(held)
+	}
+	return c
+}
+
+// dominant returns the most frequent line break and whether it is the only
+// kind seen
+func (c lineBreaks) dominant() (string, bool) {
+	c = c.done()
+	cr := c.cr
+	if c.pendingCR {
+		cr++
+	}
+	switch {
+	case c.crlf == 0 && c.lf == 0 && cr == 0:
+		return "", false
+	case c.crlf >= c.lf && c.crlf >= cr:
+		return "\r\n", c.lf == 0 && cr == 0
+	case c.lf >= cr:
+		return "\n", c.crlf == 0 && cr == 0
+	default:
+		return "\r", c.crlf == 0 && c.lf == 0
+	}
+}
+
+// LineEnding returns the line break the input uses, "\n", "\r\n" or "\r",
+// for writing it back with EmitterOptions.LineEnding. If the input mixes
+// them, the most frequent is returned with ok false; if it has no line
+// breaks, "" is. Line breaks are counted in the input read so far, all of
+// it for a parser reading from a byte slice or string, after decoding it
+// from UTF-16 if need be.
+func (p *Parser) LineEnding() (ending string, ok bool) {
+	if p.blank {
+		return "", false
+	}
+	if p.input != nil {
+		return p.inputBreaks().dominant()
+	}
+	if p.reader == nil {
+		return "", false
+	}
+	return p.reader.breaks.dominant()
+}
+
+// TrailingNewline reports whether the input ends with a line break, for
+// writing it back with EmitterOptions.OmitTrailingNewline. For a parser
+// reading from a reader, it reports on the input read so far, which is all
+// of it once STREAM-END has been returned.
+func (p *Parser) TrailingNewline() bool {
+	if p.input != nil {
+		return !p.blank && p.inputBreaks().trailing
+	}
+	return p.reader != nil && p.reader.breaks.done().trailing
+}
+
+// inputBreaks counts the line breaks in the in-memory input
+func (p *Parser) inputBreaks() lineBreaks {
+	breaks := lineBreaks{encoding: p.parser.encoding}
+	breaks.add(p.input)
+	return breaks.done()
+}
+
+// trailingBreakWriter holds back the line breaks at the end of what has
+// been written, so that the last of them can be dropped
+type trailingBreakWriter struct {
+	writer io.Writer
+	held   []byte
+}
+
+func (w *trailingBreakWriter) Write(b []byte) (int, error) {
+	i := len(bytes.TrimRight(b, "\r\n"))
+	if i > 0 {
+		if _, err := w.writer.Write(w.held); err != nil {
+			return 0, err
+		}
+		w.held = w.held[:0]
+		if _, err := w.writer.Write(b[:i]); err != nil {
+			return 0, err
+		}
+	}
+	w.held = append(w.held, b[i:]...)
+	return len(b), nil
+}
+
+// finish writes the held line breaks but the last
+func (w *trailingBreakWriter) finish() error {
+	held := w.held
+	switch {
+	case bytes.HasSuffix(held, []byte("\r\n")):
+		held = held[:len(held)-2]
+	case len(held) > 0:
+		held = held[:len(held)-1]
+	}
+	w.held = nil
+	_, err := w.writer.Write(held)
+	return err
+}
@@ -0,0 +1,121 @@
+package yaml
+
+import (
+	"bytes"
+	"testing"
+	"testing/iotest"
+)
+
+// drain reads all events from the parser
+func drain(t *testing.T, p *Parser) {
+	t.Helper()
+	for {
+		event, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			return
+		}
+	}
+}
+
+// newlineSources returns parsers reading src from a byte slice and, one
+// byte at a time, from a reader
+func newlineSources(t *testing.T, src []byte, opts ...ParserOption) map[string]*Parser {
+	t.Helper()
+	fromBytes, err := NewParserFromBytes(src, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromReader, err := NewParser(iotest.OneByteReader(bytes.NewReader(src)), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]*Parser{"bytes": fromBytes, "reader": fromReader}
+}
+
+func TestLineEnding(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    []byte
+		opts   []ParserOption
+		ending string
+		ok     bool
+	}{
+		{"lf", []byte("a: 1\nb: 2\n"), nil, "\n", true},
+		{"crlf", []byte("a: 1\r\nb: 2\r\n"), nil, "\r\n", true},
+		{"cr", []byte("a: 1\rb: 2\r"), nil, "\r", true},
+		{"mixed", []byte("a: 1\r\nb: 2\r\nc: 3\n"), nil, "\r\n", false},
+		{"none", []byte("a: 1"), nil, "", false},
+		{"single line break", []byte("\n"), nil, "\n", true},
+		{"utf-16le", encodeUTF16("a: 1\r\nb: 2\r\n", false, true), nil, "\r\n", true},
+		{"utf-16be", encodeUTF16("a: 1\nb: 2\n", true, true), nil, "\n", true},
+		{"utf-16 mixed", encodeUTF16("a: 1\rb: 2\r\nc: 3\r", false, true), nil, "\r", false},
+		{"forced utf-16", encodeUTF16("a: 1\r\n", true, false), []ParserOption{WithEncoding(EncodingUTF16BE)}, "\r\n", true},
+	}
+	for _, tt := range tests {
+		for source, p := range newlineSources(t, tt.src, tt.opts...) {
+			t.Run(tt.name+"/"+source, func(t *testing.T) {
+				defer p.Close()
+				drain(t, p)
+				if ending, ok := p.LineEnding(); ending != tt.ending || ok != tt.ok {
+					t.Errorf("got %q, %v, want %q, %v", ending, ok, tt.ending, tt.ok)
+				}
+			})
+		}
+	}
+}
+
+func TestTrailingNewline(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+		want bool
+	}{
+		{"lf", []byte("a: 1\n"), true},
+		{"crlf", []byte("a: 1\r\n"), true},
+		{"cr", []byte("a: 1\r"), true},
+		{"none", []byte("a: 1"), false},
+		{"after a comment", []byte("a: 1\n# end\n"), true},
+		{"comment without one", []byte("a: 1\n# end"), false},
+		{"empty", []byte(""), false},
+		{"utf-16", encodeUTF16("a: 1\n", false, true), true},
+		{"utf-16 without one", encodeUTF16("a: 1", true, true), false},
+	}
+	for _, tt := range tests {
+		for source, p := range newlineSources(t, tt.src) {
+			t.Run(tt.name+"/"+source, func(t *testing.T) {
+				defer p.Close()
+				drain(t, p)
+				if got := p.TrailingNewline(); got != tt.want {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+
+	for _, src := range []string{"a: 1\nb: [x]\n", "a: 1\nb: [x]", "- x\r\n- y"} {
+		p, err := NewParserFromString(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var events []*Event
+		for {
+			event, err := p.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event == nil {
+				break
+			}
+			events = append(events, event)
+		}
+		ending, _ := p.LineEnding()
+		out := emitEvents(t, events, EmitterOptions{LineEnding: ending, OmitTrailingNewline: !p.TrailingNewline()})
+		p.Close()
+		if out != src {
+			t.Errorf("%q written back as %q", src, out)
+		}
+	}
+}
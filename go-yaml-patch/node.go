@@ -0,0 +1,323 @@
+package yaml
+
+import (
+	"fmt"
+	"io"
+)
+
+// eventSource is a stream of events that can be read one at a time, such
+// as a Parser
+type eventSource interface {
+	Next() (*Event, error)
+	Peek() (*Event, error)
+}
+
+// eventSlice is an eventSource reading from a slice of events
+type eventSlice []*Event
+
+func (s *eventSlice) Next() (*Event, error) {
+	event, err := s.Peek()
+	if event != nil {
+		*s = (*s)[1:]
+	}
+	return event, err
+}
+
+func (s *eventSlice) Peek() (*Event, error) {
+	if len(*s) == 0 {
+		return nil, nil
+	}
+	return (*s)[0], nil
+}
+
+// BuildNodes assembles the given events into Node trees, returning one
+// DocumentNode per document in the stream. Tags, anchors, styles, comments
+// and positions are carried over the same way Unmarshal into a Node sets
+// them. STREAM-START and STREAM-END events are optional.
+func BuildNodes(events []*Event) ([]*Node, error) {
+	source := eventSlice(events)
+	b := nodeBuilder{events: &source}
+	var docs []*Node
+	for {
+		event, err := b.events.Next()
+		if err != nil || event == nil {
+			return docs, err
+		}
+		switch event.Type {
+		case EventStreamStart, EventStreamEnd:
+			continue
+		case EventDocumentStart:
+			doc, err := b.document(event)
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, doc)
+		default:
+			return nil, fmt.Errorf("unexpected %v event outside a document", event.Type)
+		}
+	}
+}
+
+// Decode consumes the next document in the stream and returns it as a
+// DocumentNode, or nil once the stream has no more documents. Events up to
+// the next DOCUMENT-START are skipped.
+func (p *Parser) Decode() (*Node, error) {
+	b := nodeBuilder{events: p}
+	for {
+		event, err := p.Next()
+		if err != nil || event == nil {
+			return nil, err
+		}
+		if event.Type == EventDocumentStart {
+			return b.document(event)
+		}
+	}
+}
+
+// DecodeValue consumes the next complete node in the stream, a scalar, an
+// alias or a whole collection, and unmarshals it into v as Node.Decode
+// does. STREAM-START and DOCUMENT-START events before the node are skipped.
+// Afterwards the parser is positioned just after the node's last event.
+// To decode the items of a large sequence one at a time, consume its
+// SEQUENCE-START with Next first; otherwise the whole sequence is decoded.
+//
+// DecodeValue returns io.EOF at the end of the stream, and an error without
+// consuming anything when the next event ends a collection or document.
+// Aliases in the node must refer to anchors defined within it.
+func (p *Parser) DecodeValue(v interface{}) error {
+	event, err := p.Peek()
+	for err == nil && event != nil &&
+		(event.Type == EventStreamStart || event.Type == EventDocumentStart) {
+		p.Next()
+		event, err = p.Peek()
+	}
+	switch {
+	case err != nil:
+		return err
+	case event == nil || event.Type == EventStreamEnd:
+		return io.EOF
+	case !event.Type.IsContent():
+		return fmt.Errorf("%s: expected a node, got %v",
+			position(event.StartMark), event.Type)
+	}
+
+	p.Next()
+	b := nodeBuilder{events: p}
+	n, err := b.node(event)
+	if err != nil {
+		return err
+	}
+	return n.Decode(v)
+}
+
+// nodeBuilder assembles Nodes from an event stream, mirroring the Node
+// decoder of the underlying package
+type nodeBuilder struct {
+	events  eventSource
+	anchors map[string]*Node
+}
+
+// next returns the next event, failing if the stream ends inside a node
+func (b *nodeBuilder) next() (*Event, error) {
+	event, err := b.events.Next()
+	if err == nil && event == nil {
+		err = fmt.Errorf("unexpected end of events")
+	}
+	return event, err
+}
+
+// peek returns the next event without consuming it, failing if the stream
+// ends inside a node
+func (b *nodeBuilder) peek() (*Event, error) {
+	event, err := b.events.Peek()
+	if err == nil && event == nil {
+		err = fmt.Errorf("unexpected end of events")
+	}
+	return event, err
+}
+
+// document builds a DocumentNode from its DOCUMENT-START event through the
+// matching DOCUMENT-END
+func (b *nodeBuilder) document(start *Event) (*Node, error) {
+	b.anchors = nil
+	n := b.newNode(DocumentNode, "", start)
+	event, err := b.next()
+	if err != nil {
+		return nil, err
+	}
+	child, err := b.node(event)
+	if err != nil {
+		return nil, err
+	}
+	n.Content = append(n.Content, child)
+	if event, err = b.next(); err != nil {
+		return nil, err
+	}
+	if event.Type != EventDocumentEnd {
+		return nil, fmt.Errorf("expected DOCUMENT-END, got %v", event.Type)
+	}
+	n.FootComment = string(event.FootComment)
+	return n, nil
+}
+
+// node builds the node that starts with the given content event
+func (b *nodeBuilder) node(event *Event) (*Node, error) {
+	switch event.Type {
+	case EventScalar:
+		return b.scalar(event), nil
+	case EventAlias:
+		n := b.newNode(AliasNode, "", event)
+		n.Value = event.Anchor
+		n.Alias = b.anchors[event.Anchor]
+		if n.Alias == nil {
+			return nil, fmt.Errorf("%s: unknown anchor %q referenced",
+				position(event.StartMark), event.Anchor)
+		}
+		return n, nil
+	case EventSequenceStart:
+		return b.sequence(event)
+	case EventMappingStart:
+		return b.mapping(event)
+	default:
+		return nil, fmt.Errorf("unexpected %v event, expected a node", event.Type)
+	}
+}
+
+// newNode creates a node of the given kind from its first event, resolving
+// its tag as the Node decoder does
+func (b *nodeBuilder) newNode(kind Kind, defaultTag string, event *Event) *Node {
+	n := &Node{
+		Kind:        kind,
+		Line:        event.StartMark.Line + 1,
+		Column:      event.StartMark.Column + 1,
+		HeadComment: string(event.HeadComment),
+		LineComment: string(event.LineComment),
+		FootComment: string(event.FootComment),
+	}
+	switch {
+	case event.Tag != "" && event.Tag != "!":
+		n.Tag = shortTag(event.fullTag())
+		n.Style = TaggedStyle
+	case defaultTag != "":
+		n.Tag = defaultTag
+	case kind == ScalarNode:
+		n.Tag, _ = resolve("", event.Value)
+	}
+	return n
+}
+
+// anchor registers the node under the anchor of its event, if any
+func (b *nodeBuilder) anchor(n *Node, event *Event) {
+	if event.Anchor == "" {
+		return
+	}
+	n.Anchor = event.Anchor
+	if b.anchors == nil {
+		b.anchors = make(map[string]*Node)
+	}
+	b.anchors[n.Anchor] = n
+}
+
+func (b *nodeBuilder) scalar(event *Event) *Node {
+	var style Style
+	switch yaml_scalar_style_t(event.Style) {
+	case yaml_DOUBLE_QUOTED_SCALAR_STYLE:
+		style = DoubleQuotedStyle
+	case yaml_SINGLE_QUOTED_SCALAR_STYLE:
+		style = SingleQuotedStyle
+	case yaml_LITERAL_SCALAR_STYLE:
+		style = LiteralStyle
+	case yaml_FOLDED_SCALAR_STYLE:
+		style = FoldedStyle
+	}
+	var defaultTag string
+	if style != 0 {
+		defaultTag = strTag
+	} else if event.Value == "<<" {
+		defaultTag = mergeTag
+	}
+	n := b.newNode(ScalarNode, defaultTag, event)
+	n.Value = event.Value
+	n.Style |= style
+	b.anchor(n, event)
+	return n
+}
+
+func (b *nodeBuilder) sequence(start *Event) (*Node, error) {
+	n := b.newNode(SequenceNode, seqTag, start)
+	if yaml_sequence_style_t(start.Style) == yaml_FLOW_SEQUENCE_STYLE {
+		n.Style |= FlowStyle
+	}
+	b.anchor(n, start)
+	for {
+		event, err := b.next()
+		if err != nil {
+			return nil, err
+		}
+		if event.Type == EventSequenceEnd {
+			n.LineComment = string(event.LineComment)
+			n.FootComment = string(event.FootComment)
+			return n, nil
+		}
+		child, err := b.node(event)
+		if err != nil {
+			return nil, err
+		}
+		n.Content = append(n.Content, child)
+	}
+}
+
+func (b *nodeBuilder) mapping(start *Event) (*Node, error) {
+	n := b.newNode(MappingNode, mapTag, start)
+	block := yaml_mapping_style_t(start.Style) != yaml_FLOW_MAPPING_STYLE
+	if !block {
+		n.Style |= FlowStyle
+	}
+	b.anchor(n, start)
+	for {
+		event, err := b.next()
+		if err != nil {
+			return nil, err
+		}
+		if event.Type == EventMappingEnd {
+			n.LineComment = string(event.LineComment)
+			n.FootComment = string(event.FootComment)
+			if block && n.FootComment != "" && len(n.Content) > 1 {
+				n.Content[len(n.Content)-2].FootComment = n.FootComment
+				n.FootComment = ""
+			}
+			return n, nil
+		}
+
+		k, err := b.node(event)
+		if err != nil {
+			return nil, err
+		}
+		// A key's foot comment belongs to the previous entry when the
+		// previous value ended with a dedent
+		if block && k.FootComment != "" && len(n.Content) > 1 {
+			n.Content[len(n.Content)-2].FootComment = k.FootComment
+			k.FootComment = ""
+		}
+		if event, err = b.next(); err != nil {
+			return nil, err
+		}
+		v, err := b.node(event)
+		if err != nil {
+			return nil, err
+		}
+		if k.FootComment == "" && v.FootComment != "" {
+			k.FootComment, v.FootComment = v.FootComment, ""
+		}
+		// The foot comment of a nested value arrives as the tail comment
+		// of the following key or mapping end
+		following, err := b.peek()
+		if err != nil {
+			return nil, err
+		}
+		if k.FootComment == "" && following.TailComment != nil {
+			k.FootComment = string(following.TailComment)
+		}
+		n.Content = append(n.Content, k, v)
+	}
+}
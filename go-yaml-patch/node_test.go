@@ -0,0 +1,163 @@
+package yaml
+
+import (
+	"io"
+	"testing"
+)
+
+// compareNodes reports the differences between the node got and the node
+// want, which Unmarshal built
+func compareNodes(t *testing.T, path string, got, want *Node) {
+	t.Helper()
+	if got.Kind != want.Kind || got.Tag != want.Tag || got.Value != want.Value ||
+		got.Anchor != want.Anchor || got.Style != want.Style {
+		t.Errorf("%s: got kind %v, tag %q, value %q, anchor %q, style %v, want %v, %q, %q, %q, %v", path,
+			got.Kind, got.Tag, got.Value, got.Anchor, got.Style,
+			want.Kind, want.Tag, want.Value, want.Anchor, want.Style)
+	}
+	if got.Line != want.Line || got.Column != want.Column {
+		t.Errorf("%s: got line %d, column %d, want %d, %d", path, got.Line, got.Column, want.Line, want.Column)
+	}
+	if got.HeadComment != want.HeadComment || got.LineComment != want.LineComment || got.FootComment != want.FootComment {
+		t.Errorf("%s: got comments %q %q %q, want %q %q %q", path,
+			got.HeadComment, got.LineComment, got.FootComment,
+			want.HeadComment, want.LineComment, want.FootComment)
+	}
+	if (got.Alias == nil) != (want.Alias == nil) ||
+		got.Alias != nil && (got.Alias.Anchor != want.Alias.Anchor || got.Alias.Line != want.Alias.Line) {
+		t.Errorf("%s: alias target differs", path)
+	}
+	if len(got.Content) != len(want.Content) {
+		t.Errorf("%s: got %d children, want %d", path, len(got.Content), len(want.Content))
+		return
+	}
+	for i := range want.Content {
+		compareNodes(t, path+"/"+want.Content[i].Value, got.Content[i], want.Content[i])
+	}
+}
+
+// nodeDocs are documents whose nodes BuildNodes must build as Unmarshal
+// into a Node does
+var nodeDocs = []string{
+	"a: 1\nb: [x, 'y', \"z\"]\nc: {d: ~, e: true}\n",
+	"# head\na: &x 1  # line\nb: *x\n",
+	"seq: &s\n- one\n- two\ncopy: *s\n",
+	"tagged: !!str 12\nlocal: !thing {a: b}\nmerge: {<<: {x: 1}}\n",
+	"text: |\n  literal\nfolded: >-\n  folded\n",
+	"--- [first]\n...\n",
+}
+
+func TestBuildNodes(t *testing.T) {
+	for _, src := range nodeDocs {
+		got, err := BuildNodes(parseEvents(t, src))
+		if err != nil {
+			t.Fatalf("building %q: %v", src, err)
+		}
+		var want Node
+		if err := Unmarshal([]byte(src), &want); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("%q: got %d documents, want 1", src, len(got))
+		}
+		compareNodes(t, src, got[0], &want)
+	}
+
+	docs, err := BuildNodes(parseEvents(t, "--- first\n--- [second]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[0].Content[0].Value != "first" || docs[1].Content[0].Kind != SequenceNode {
+		t.Errorf("two documents built as %d nodes", len(docs))
+	}
+}
+
+func TestBuildNodesAliases(t *testing.T) {
+	// Without STREAM-START and STREAM-END
+	events := parseDocuments(t, "a: &x [1]\nb: *x\n")[0]
+	docs, err := BuildNodes(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := docs[0].Content[0]
+	if alias := root.Content[3]; alias.Kind != AliasNode || alias.Alias != root.Content[1] {
+		t.Errorf("alias *x does not point to the node anchored &x")
+	}
+
+	if _, err := BuildNodes(parseEvents(t, "a: *missing\n")); err == nil {
+		t.Error("an alias to an unknown anchor gave no error")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	p, err := NewParserFromString("a: 1\n--- [x]\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	for _, kind := range []Kind{MappingNode, SequenceNode} {
+		doc, err := p.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc == nil || doc.Kind != DocumentNode || doc.Content[0].Kind != kind {
+			t.Fatalf("got %v, want a document holding a %v", doc, kind)
+		}
+	}
+	if doc, err := p.Decode(); doc != nil || err != nil {
+		t.Errorf("got %v and error %v after the last document", doc, err)
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	const src = "- a: 1\n- a: 2\n"
+
+	// Without consuming SEQUENCE-START the whole sequence is one value
+	p, err := NewParserFromString(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all []map[string]int
+	if err := p.DecodeValue(&all); err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[1]["a"] != 2 {
+		t.Errorf("decoded the root sequence as %v", all)
+	}
+	p.Close()
+
+	p, err = NewParserFromString(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	for {
+		event, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Type == EventSequenceStart {
+			break
+		}
+	}
+	for i := 1; i <= 2; i++ {
+		var item struct{ A int }
+		if err := p.DecodeValue(&item); err != nil {
+			t.Fatal(err)
+		}
+		if item.A != i {
+			t.Errorf("item %d decoded as %v", i, item)
+		}
+	}
+	if err := p.DecodeValue(new(interface{})); err == nil || err == io.EOF {
+		t.Errorf("got error %v at SEQUENCE-END, want a decoding error", err)
+	}
+	for _, want := range []EventType{EventSequenceEnd, EventDocumentEnd} {
+		if event, err := p.Next(); err != nil || event.Type != want {
+			t.Fatalf("got %v and error %v, want %v left unconsumed", event, err, want)
+		}
+	}
+	if err := p.DecodeValue(new(interface{})); err != io.EOF {
+		t.Errorf("got error %v at the end of the stream, want io.EOF", err)
+	}
+}
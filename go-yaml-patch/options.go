@@ -0,0 +1,90 @@
+package yaml
+
+// ParserOption sets one of the ParserOptions. Options are passed to the
+// parser constructors, such as NewParser, and applied in order, so a later
+// option overrides an earlier one.
+type ParserOption func(*ParserOptions)
+
+// parserOptions builds ParserOptions from the given options
+func parserOptions(opts []ParserOption) ParserOptions {
+	var options ParserOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithDuplicateKeyDetection sets DetectDuplicateKeys
+func WithDuplicateKeyDetection() ParserOption {
+	return func(o *ParserOptions) { o.DetectDuplicateKeys = true }
+}
+
+// WithAliasCycleDetection sets DetectAliasCycles
+func WithAliasCycleDetection() ParserOption {
+	return func(o *ParserOptions) { o.DetectAliasCycles = true }
+}
+
+// WithAliasValidation sets ValidateAliases
+func WithAliasValidation() ParserOption {
+	return func(o *ParserOptions) { o.ValidateAliases = true }
+}
+
+// WithSkipComments sets SkipComments
+func WithSkipComments() ParserOption {
+	return func(o *ParserOptions) { o.SkipComments = true }
+}
+
+// WithEncoding forces the encoding of the input
+func WithEncoding(e Encoding) ParserOption {
+	return func(o *ParserOptions) { o.Encoding = e }
+}
+
+// WithMaxDepth sets MaxDepth
+func WithMaxDepth(n int) ParserOption {
+	return func(o *ParserOptions) { o.MaxDepth = n }
+}
+
+// WithMaxEvents sets MaxEvents
+func WithMaxEvents(n int) ParserOption {
+	return func(o *ParserOptions) { o.MaxEvents = n }
+}
+
+// WithMaxBytes sets MaxBytes
+func WithMaxBytes(n int) ParserOption {
+	return func(o *ParserOptions) { o.MaxBytes = n }
+}
+
+// WithMaxAliasExpansions sets MaxAliasExpansions
+func WithMaxAliasExpansions(n int) ParserOption {
+	return func(o *ParserOptions) { o.MaxAliasExpansions = n }
+}
+
+// WithMaxScalarBytes sets MaxScalarBytes
+func WithMaxScalarBytes(n int) ParserOption {
+	return func(o *ParserOptions) { o.MaxScalarBytes = n }
+}
+
+// WithResolveTags sets ResolveTags
+func WithResolveTags() ParserOption {
+	return func(o *ParserOptions) { o.ResolveTags = true }
+}
+
+// WithRawScalars sets RawScalars
+func WithRawScalars() ParserOption {
+	return func(o *ParserOptions) { o.RawScalars = true }
+}
+
+// WithMergeKeyResolution sets ResolveMergeKeys
+func WithMergeKeyResolution() ParserOption {
+	return func(o *ParserOptions) { o.ResolveMergeKeys = true }
+}
+
+// WithRejectTabs sets RejectTabs
+func WithRejectTabs() ParserOption {
+	return func(o *ParserOptions) { o.RejectTabs = true }
+}
+
+// WithReadBufferSize sets ReadBufferSize
+func WithReadBufferSize(n int) ParserOption {
+	return func(o *ParserOptions) { o.ReadBufferSize = n }
+}
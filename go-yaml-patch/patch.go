@@ -0,0 +1,177 @@
+package yaml
+
+import "fmt"
+
+// Patch deep-merges the document in overlay onto the document in base and
+// returns the events of the result. Mappings are merged recursively: an
+// entry whose scalar key appears in both takes the merged value of the two,
+// and entries only in overlay are added after those of base. Anything else
+// in overlay, a scalar, a sequence or an alias, replaces the node in base.
+//
+// The result keeps the events of base wherever overlay does not replace
+// them, so base's comments, styles and anchors survive, as do its stream
+// and document events. Each slice holds a single document; events after
+// its root node are ignored in overlay. It is an error for the result to
+// keep an alias whose anchor was replaced, or to take one from overlay
+// whose anchor it does not have.
+func Patch(base, overlay []*Event) ([]*Event, error) {
+	b, bEnd, err := rootNode(base)
+	if err != nil {
+		return nil, err
+	}
+	if b < 0 {
+		return nil, fmt.Errorf("patch base has no document")
+	}
+	o, oEnd, err := rootNode(overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append([]*Event(nil), base[:b]...)
+	if o < 0 {
+		out = append(out, base[b:bEnd]...)
+	} else {
+		root, err := patchNode(base[b:bEnd], overlay[o:oEnd])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, root...)
+	}
+	out = append(out, base[bEnd:]...)
+	if err := checkAnchors(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// rootNode returns the bounds of the first node in events, or -1 if there
+// is none
+func rootNode(events []*Event) (start, end int, err error) {
+	for i, event := range events {
+		if event.Type.IsContent() {
+			end, err := skipNode(events, i)
+			return i, end, err
+		}
+	}
+	return -1, -1, nil
+}
+
+// skipNode returns the index following the node that starts at events[i],
+// counting the nesting of collections rather than relying on Depth, which
+// constructed events do not set
+func skipNode(events []*Event, i int) (int, error) {
+	if !events[i].Type.IsContent() {
+		return i, fmt.Errorf("unexpected %v event, expected a node", events[i].Type)
+	}
+	for open := 0; i < len(events); i++ {
+		switch {
+		case events[i].Type.IsCollectionStart():
+			open++
+		case events[i].Type.IsCollectionEnd():
+			open--
+		}
+		if open == 0 {
+			return i + 1, nil
+		}
+	}
+	return i, fmt.Errorf("unexpected end of events")
+}
+
+// mappingEntry locates a key and value pair within a mapping's events
+type mappingEntry struct {
+	key, value, end int
+}
+
+// mappingEntries splits the events of a mapping node into its entries
+func mappingEntries(node []*Event) ([]mappingEntry, error) {
+	var entries []mappingEntry
+	for i := 1; i < len(node)-1; {
+		value, err := skipNode(node, i)
+		if err != nil {
+			return nil, err
+		}
+		if value >= len(node)-1 {
+			return nil, fmt.Errorf("%s: mapping has a key without a value",
+				position(node[i].StartMark))
+		}
+		end, err := skipNode(node, value)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, mappingEntry{key: i, value: value, end: end})
+		i = end
+	}
+	return entries, nil
+}
+
+// patchNode merges the overlay node onto the base node
+func patchNode(base, overlay []*Event) ([]*Event, error) {
+	if base[0].Type != EventMappingStart || overlay[0].Type != EventMappingStart {
+		return overlay, nil
+	}
+	baseEntries, err := mappingEntries(base)
+	if err != nil {
+		return nil, err
+	}
+	overlayEntries, err := mappingEntries(overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	// Overlay entries by scalar key; an overlay that repeats a key merges
+	// its last value, as decoding it would
+	byKey := make(map[string]mappingEntry)
+	for _, entry := range overlayEntries {
+		if key := overlay[entry.key]; key.Type == EventScalar {
+			byKey[key.Value] = entry
+		}
+	}
+
+	out := []*Event{base[0]}
+	merged := make(map[string]bool)
+	for _, entry := range baseEntries {
+		key := base[entry.key]
+		match, ok := byKey[key.Value]
+		if key.Type != EventScalar || !ok {
+			out = append(out, base[entry.key:entry.end]...)
+			continue
+		}
+		value, err := patchNode(base[entry.value:entry.end], overlay[match.value:match.end])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, base[entry.key:entry.value]...)
+		out = append(out, value...)
+		merged[key.Value] = true
+	}
+	for _, entry := range overlayEntries {
+		key := overlay[entry.key]
+		if key.Type == EventScalar {
+			if merged[key.Value] || byKey[key.Value] != entry {
+				continue
+			}
+		}
+		out = append(out, overlay[entry.key:entry.end]...)
+	}
+	return append(out, base[len(base)-1]), nil
+}
+
+// checkAnchors returns an error if an alias in the given events refers to
+// an anchor not defined before it in the same document
+func checkAnchors(events []*Event) error {
+	anchors := make(map[string]bool)
+	for _, event := range events {
+		switch {
+		case event.Type == EventDocumentStart:
+			anchors = make(map[string]bool)
+		case event.Type == EventAlias:
+			if !anchors[event.Anchor] {
+				return fmt.Errorf("%s: alias *%s has no anchor before it in the document",
+					position(event.StartMark), event.Anchor)
+			}
+		case event.Anchor != "":
+			anchors[event.Anchor] = true
+		}
+	}
+	return nil
+}
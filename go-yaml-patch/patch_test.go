@@ -0,0 +1,94 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+)
+
+// keyOrder returns the values of the scalar keys in the given events, in
+// order
+func keyOrder(events []*Event) string {
+	var keys []string
+	for _, event := range events {
+		if event.IsKey && event.Type == EventScalar {
+			keys = append(keys, event.Value)
+		}
+	}
+	return strings.Join(keys, " ")
+}
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		base, overlay string
+		want          string
+		keys          string
+	}{
+		{
+			"recursive merge",
+			"a: 1\nb:\n  x: 1\n  y: 2\n", "b:\n  y: 3\n  z: 4\nc: 5\n",
+			"a: 1\nb: {x: 1, y: 3, z: 4}\nc: 5\n", "a b x y z c",
+		},
+		{
+			"replace non-mappings",
+			"a: [1, 2]\nb: {x: 1}\nc: text\n", "a: [3]\nb: text\nc: {x: 1}\n",
+			"a: [3]\nb: text\nc: {x: 1}\n", "a b c x",
+		},
+		{"replace the root", "a: 1\n", "--- [2]\n", "--- [2]\n", ""},
+		{"empty overlay", "a: 1\n", "", "a: 1\n", "a"},
+		{"alias to a kept anchor", "a: &x 1\n", "b: *x\n", "a: 1\nb: 1\n", "a b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Patch(parseEvents(t, tt.base), parseEvents(t, tt.overlay))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ops, err := Diff(parseEvents(t, tt.want), got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ops) != 0 {
+				t.Errorf("patched document differs from %q at %q", tt.want, ops[0].Path)
+			}
+			if keys := keyOrder(got); keys != tt.keys {
+				t.Errorf("keys are in the order %q, want %q", keys, tt.keys)
+			}
+		})
+	}
+}
+
+func TestPatchKeepsBase(t *testing.T) {
+	got, err := Patch(parseEvents(t, "# head\na: 'x'  # line\nb: 1\n"), parseEvents(t, "b: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := scalarNamed("a")(got)
+	x := scalarNamed("x")(got)
+	if a == nil || x == nil {
+		t.Fatal("base entry not found")
+	}
+	if string(a.HeadComment) != "# head" || x.Style != StyleSingleQuoted {
+		t.Errorf("base entry lost its comment or style: %q, %v", a.HeadComment, x.Style)
+	}
+}
+
+func TestPatchAnchors(t *testing.T) {
+	tests := []struct {
+		name          string
+		base, overlay string
+	}{
+		{"replaced anchor", "a: &x 1\nb: *x\n", "a: 2\n"},
+		{"overlay alias without anchor", "a: 1\n", "b: *y\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Patch(parseEvents(t, tt.base), parseEvents(t, tt.overlay)); err == nil {
+				t.Error("patch left an alias without its anchor and gave no error")
+			}
+		})
+	}
+	if _, err := Patch(parseEvents(t, ""), parseEvents(t, "a: 1\n")); err == nil {
+		t.Error("patching a stream without a document gave no error")
+	}
+}
@@ -0,0 +1,168 @@
+package yaml
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// PushParser parses a YAML stream whose input is pushed to it in chunks
+// with Feed, rather than pulled from a reader, and hands out each event
+// once enough input has arrived to complete it. Call End once the last
+// chunk has been fed, so the parser can finish the events that only the
+// end of the stream completes, such as a plain scalar on the last line.
+//
+// The underlying parser runs in its own goroutine and is only ever
+// waiting for input or for its event to be taken, so neither Feed nor
+// NextReady blocks on I/O. A PushParser must be closed with Close to stop
+// that goroutine. Its methods must not be called concurrently.
+type PushParser struct {
+	parser *Parser
+
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	input   []byte // input fed but not yet read by the parser
+	ended   bool   // End has been called
+	starved bool   // the parser is waiting for input
+	closed  bool   // Close has been called
+	exited  bool   // the parse goroutine has returned
+
+	// event and err hold the result of the parser's latest call to Next
+	// until NextReady takes it, when ready is set
+	event *Event
+	err   error
+	ready bool
+	done  bool // the stream has ended or failed
+}
+
+// NewPushParser creates a new YAML parser fed with Feed, with the given
+// options
+func NewPushParser(opts ...ParserOption) (*PushParser, error) {
+	return NewPushParserWithOptions(parserOptions(opts))
+}
+
+// NewPushParserWithOptions creates a new YAML parser fed with Feed using
+// the given options
+func NewPushParserWithOptions(options ParserOptions) (*PushParser, error) {
+	pp := &PushParser{}
+	pp.cond = sync.NewCond(&pp.mu)
+	parser, err := NewParserWithOptions(pushReader{pp}, options)
+	if err != nil {
+		return nil, err
+	}
+	pp.parser = parser
+	go pp.run()
+	return pp, nil
+}
+
+// Feed appends the next chunk of input. The chunk is copied, so b may be
+// reused once Feed returns. Feeding after End is an error.
+func (pp *PushParser) Feed(b []byte) error {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	if pp.ended || pp.closed {
+		return fmt.Errorf("cannot feed a push parser after the end of its input")
+	}
+	pp.input = append(pp.input, b...)
+	pp.cond.Broadcast()
+	return nil
+}
+
+// End marks the end of the input
+func (pp *PushParser) End() {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	pp.ended = true
+	pp.cond.Broadcast()
+}
+
+// NextReady returns the next event of the stream if the input fed so far
+// completes it. ok is false when the parser needs more input first. Once
+// the stream has ended, NextReady returns a nil event with ok set, as Next
+// does, and after an error it keeps returning that error.
+func (pp *PushParser) NextReady() (event *Event, ok bool, err error) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	for !pp.ready && !pp.done && !(pp.starved && len(pp.input) == 0 && !pp.ended) {
+		pp.cond.Wait()
+	}
+	switch {
+	case pp.ready:
+		event, err = pp.event, pp.err
+		pp.event, pp.ready = nil, false
+		if event == nil || err != nil {
+			pp.done = true
+		}
+		pp.cond.Broadcast()
+		return event, true, err
+	case pp.done:
+		return nil, true, pp.err
+	}
+	return nil, false, nil
+}
+
+// Close stops the parser and releases its resources
+func (pp *PushParser) Close() {
+	pp.mu.Lock()
+	pp.closed = true
+	pp.cond.Broadcast()
+	for !pp.exited {
+		pp.cond.Wait()
+	}
+	pp.mu.Unlock()
+	pp.parser.Close()
+}
+
+// run is the parse goroutine, which produces one event at a time and waits
+// for NextReady to take it before parsing on
+func (pp *PushParser) run() {
+	pp.mu.Lock()
+	defer func() {
+		pp.exited = true
+		pp.cond.Broadcast()
+		pp.mu.Unlock()
+	}()
+	for !pp.closed {
+		pp.mu.Unlock()
+		event, err := pp.parser.Next()
+		pp.mu.Lock()
+
+		pp.event, pp.err, pp.ready = event, err, true
+		pp.cond.Broadcast()
+		for pp.ready && !pp.closed {
+			pp.cond.Wait()
+		}
+		if event == nil || err != nil {
+			pp.done = true
+			return
+		}
+	}
+}
+
+// pushReader is the input of a PushParser's underlying parser, handing it
+// the fed input and waiting while there is none
+type pushReader struct {
+	pp *PushParser
+}
+
+func (r pushReader) Read(b []byte) (int, error) {
+	pp := r.pp
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	for len(pp.input) == 0 && !pp.ended && !pp.closed {
+		pp.starved = true
+		pp.cond.Broadcast()
+		pp.cond.Wait()
+	}
+	pp.starved = false
+	if pp.closed {
+		return 0, fmt.Errorf("push parser closed")
+	}
+	if len(pp.input) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, pp.input)
+	pp.input = pp.input[n:]
+	return n, nil
+}
@@ -0,0 +1,99 @@
+package yaml
+
+import (
+	"fmt"
+	"testing"
+)
+
+// pushSource has a byte order mark, multi-byte characters and comments, so
+// that splitting it at every byte cuts through each of them
+const pushSource = "\ufeffa: café  # line\n# head\nb: [ü, &x 😀, *x]\nc: |\n  text\nd: plain end"
+
+// describeEvent formats the fields of an event that do not depend on how
+// the parser reads its input
+func describeEvent(e *Event) string {
+	return fmt.Sprintf("%v %q &%s %s %d:%d:%d %q %q %q",
+		e.Type, e.Value, e.Anchor, e.Tag,
+		e.StartMark.Line, e.StartMark.Column, e.StartMark.Index,
+		e.HeadComment, e.LineComment, e.FootComment)
+}
+
+// pushEvents feeds the given chunks to a push parser and returns its
+// events, taking each one as soon as NextReady has it
+func pushEvents(t *testing.T, chunks [][]byte) []string {
+	t.Helper()
+	pp, err := NewPushParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pp.Close()
+	var events []string
+	drain := func() bool {
+		for {
+			event, ok, err := pp.NextReady()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				return false
+			}
+			if event == nil {
+				return true
+			}
+			events = append(events, describeEvent(event))
+		}
+	}
+	for _, chunk := range chunks {
+		if err := pp.Feed(chunk); err != nil {
+			t.Fatal(err)
+		}
+		if drain() {
+			t.Fatal("stream ended before End")
+		}
+	}
+	pp.End()
+	if !drain() {
+		t.Fatal("stream did not end after End")
+	}
+	return events
+}
+
+func TestPushParser(t *testing.T) {
+	var want []string
+	for _, event := range parseEvents(t, pushSource) {
+		want = append(want, describeEvent(event))
+	}
+	check := func(name string, got []string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %d events, want %d", name, len(got), len(want))
+		}
+		for k := range want {
+			if got[k] != want[k] {
+				t.Errorf("%s: event %d is %s, want %s", name, k, got[k], want[k])
+			}
+		}
+	}
+
+	src := []byte(pushSource)
+	for i := 0; i <= len(src); i++ {
+		check(fmt.Sprintf("split at %d", i), pushEvents(t, [][]byte{src[:i], src[i:]}))
+	}
+	var bytes [][]byte
+	for i := range src {
+		bytes = append(bytes, src[i:i+1])
+	}
+	check("byte by byte", pushEvents(t, bytes))
+}
+
+func TestPushParserFeedAfterEnd(t *testing.T) {
+	pp, err := NewPushParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pp.Close()
+	pp.End()
+	if err := pp.Feed([]byte("a")); err == nil {
+		t.Error("feeding after End gave no error")
+	}
+}
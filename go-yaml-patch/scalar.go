@@ -0,0 +1,193 @@
+package yaml
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Core schema patterns for plain scalars (YAML 1.2, section 10.3.2)
+var (
+	coreIntPattern   = regexp.MustCompile(`^(?:[-+]?[0-9]+|0o[0-7]+|0x[0-9a-fA-F]+)$`)
+	coreFloatPattern = regexp.MustCompile(`^(?:[-+]?(?:\.[0-9]+|[0-9]+(?:\.[0-9]*)?)(?:[eE][-+]?[0-9]+)?|[-+]?\.(?:inf|Inf|INF)|\.(?:nan|NaN|NAN))$`)
+)
+
+// ResolvedTag returns the tag the event's node resolves to under the YAML
+// 1.2 core schema. Explicit tags are returned unchanged. Untagged plain
+// scalars are resolved by value to null, bool, int, float or str, while
+// quoted and block scalars and those with the non-specific "!" tag are
+// always str. Untagged collections resolve to seq or map, and all other
+// events return the empty string.
+func (e *Event) ResolvedTag() string {
+	switch e.Type {
+	case EventScalar:
+		return e.resolveScalarTag()
+	case EventSequenceStart:
+		if e.Tag != "" {
+			return e.fullTag()
+		}
+		return yaml_SEQ_TAG
+	case EventMappingStart:
+		if e.Tag != "" {
+			return e.fullTag()
+		}
+		return yaml_MAP_TAG
+	default:
+		return ""
+	}
+}
+
+// resolveScalarTag implements ResolvedTag for scalar events
+func (e *Event) resolveScalarTag() string {
+	switch {
+	case e.Tag == "!":
+		return yaml_STR_TAG
+	case e.Tag != "":
+		return e.fullTag()
+	}
+	if !e.IsPlain() {
+		return yaml_STR_TAG
+	}
+
+	switch e.Value {
+	case "", "~", "null", "Null", "NULL":
+		return yaml_NULL_TAG
+	case "true", "True", "TRUE", "false", "False", "FALSE":
+		return yaml_BOOL_TAG
+	}
+	if coreIntPattern.MatchString(e.Value) {
+		return yaml_INT_TAG
+	}
+	if coreFloatPattern.MatchString(e.Value) {
+		return yaml_FLOAT_TAG
+	}
+	return yaml_STR_TAG
+}
+
+// IsPlain reports whether the event is a plain scalar. Scalars built with
+// no style, which the emitter writes plain where it can, count as plain.
+func (e *Event) IsPlain() bool {
+	if e.Type != EventScalar {
+		return false
+	}
+	style := yaml_scalar_style_t(e.Style)
+	return style == yaml_ANY_SCALAR_STYLE || style == yaml_PLAIN_SCALAR_STYLE
+}
+
+// IsQuoted reports whether the event is a single or double quoted scalar
+func (e *Event) IsQuoted() bool {
+	if e.Type != EventScalar {
+		return false
+	}
+	style := yaml_scalar_style_t(e.Style)
+	return style == yaml_SINGLE_QUOTED_SCALAR_STYLE || style == yaml_DOUBLE_QUOTED_SCALAR_STYLE
+}
+
+// AsInt returns the value of a scalar that resolves to an integer. Decimal,
+// octal ("0o17") and hexadecimal ("0x1A") forms are accepted; ok is false
+// for any other scalar or a value that does not fit in an int64.
+func (e *Event) AsInt() (value int64, ok bool) {
+	negative, u, ok := e.parseInt()
+	switch {
+	case !ok:
+		return 0, false
+	case negative:
+		if u > 1<<63 {
+			return 0, false
+		}
+		return -int64(u), true
+	case u > math.MaxInt64:
+		return 0, false
+	default:
+		return int64(u), true
+	}
+}
+
+// AsUint returns the value of a scalar that resolves to a non-negative
+// integer; ok is false for any other scalar
+func (e *Event) AsUint() (value uint64, ok bool) {
+	negative, u, ok := e.parseInt()
+	if !ok || (negative && u != 0) {
+		return 0, false
+	}
+	return u, true
+}
+
+// parseInt splits an integer scalar into its sign and magnitude
+func (e *Event) parseInt() (negative bool, u uint64, ok bool) {
+	if e.ResolvedTag() != yaml_INT_TAG {
+		return false, 0, false
+	}
+	digits := e.Value
+	if digits != "" && (digits[0] == '-' || digits[0] == '+') {
+		negative = digits[0] == '-'
+		digits = digits[1:]
+	}
+	base := 10
+	switch {
+	case strings.HasPrefix(digits, "0o"):
+		base, digits = 8, digits[2:]
+	case strings.HasPrefix(digits, "0x"):
+		base, digits = 16, digits[2:]
+	}
+	u, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		return false, 0, false
+	}
+	return negative, u, true
+}
+
+// AsFloat returns the value of a scalar that resolves to a float or an
+// integer, including the special ".inf", "-.inf" and ".nan" forms; ok is
+// false for any other scalar
+func (e *Event) AsFloat() (value float64, ok bool) {
+	switch e.ResolvedTag() {
+	case yaml_INT_TAG:
+		if i, ok := e.AsInt(); ok {
+			return float64(i), true
+		}
+		if u, ok := e.AsUint(); ok {
+			return float64(u), true
+		}
+		return 0, false
+	case yaml_FLOAT_TAG:
+	default:
+		return 0, false
+	}
+
+	switch e.Value {
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
+		return math.Inf(1), true
+	case "-.inf", "-.Inf", "-.INF":
+		return math.Inf(-1), true
+	case ".nan", ".NaN", ".NAN":
+		return math.NaN(), true
+	}
+	f, err := strconv.ParseFloat(e.Value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+// AsBool returns the value of a scalar that resolves to a boolean; ok is
+// false for any other scalar
+func (e *Event) AsBool() (value bool, ok bool) {
+	if e.ResolvedTag() != yaml_BOOL_TAG {
+		return false, false
+	}
+	switch e.Value {
+	case "true", "True", "TRUE":
+		return true, true
+	case "false", "False", "FALSE":
+		return false, true
+	}
+	return false, false
+}
+
+// IsNull reports whether the event is a scalar that resolves to null, such
+// as "null", "~" or an empty plain scalar
+func (e *Event) IsNull() bool {
+	return e.ResolvedTag() == yaml_NULL_TAG
+}
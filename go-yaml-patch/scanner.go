@@ -0,0 +1,194 @@
+package yaml
+
+import "io"
+
+// TokenType represents the type of a YAML scanner token
+type TokenType int
+
+const (
+	TokenNone TokenType = iota
+	TokenStreamStart
+	TokenStreamEnd
+	TokenVersionDirective
+	TokenTagDirective
+	TokenDocumentStart
+	TokenDocumentEnd
+	TokenBlockSequenceStart
+	TokenBlockMappingStart
+	TokenBlockEnd
+	TokenFlowSequenceStart
+	TokenFlowSequenceEnd
+	TokenFlowMappingStart
+	TokenFlowMappingEnd
+	TokenBlockEntry
+	TokenFlowEntry
+	TokenKey
+	TokenValue
+	TokenAlias
+	TokenAnchor
+	TokenTag
+	TokenScalar
+)
+
+func (t TokenType) String() string {
+	switch t {
+	case TokenStreamStart:
+		return "STREAM-START"
+	case TokenStreamEnd:
+		return "STREAM-END"
+	case TokenVersionDirective:
+		return "VERSION-DIRECTIVE"
+	case TokenTagDirective:
+		return "TAG-DIRECTIVE"
+	case TokenDocumentStart:
+		return "DOCUMENT-START"
+	case TokenDocumentEnd:
+		return "DOCUMENT-END"
+	case TokenBlockSequenceStart:
+		return "BLOCK-SEQUENCE-START"
+	case TokenBlockMappingStart:
+		return "BLOCK-MAPPING-START"
+	case TokenBlockEnd:
+		return "BLOCK-END"
+	case TokenFlowSequenceStart:
+		return "FLOW-SEQUENCE-START"
+	case TokenFlowSequenceEnd:
+		return "FLOW-SEQUENCE-END"
+	case TokenFlowMappingStart:
+		return "FLOW-MAPPING-START"
+	case TokenFlowMappingEnd:
+		return "FLOW-MAPPING-END"
+	case TokenBlockEntry:
+		return "BLOCK-ENTRY"
+	case TokenFlowEntry:
+		return "FLOW-ENTRY"
+	case TokenKey:
+		return "KEY"
+	case TokenValue:
+		return "VALUE"
+	case TokenAlias:
+		return "ALIAS"
+	case TokenAnchor:
+		return "ANCHOR"
+	case TokenTag:
+		return "TAG"
+	case TokenScalar:
+		return "SCALAR"
+	default:
+		return "NONE"
+	}
+}
+
+// tokenTypes maps the underlying scanner's token types to TokenType
+var tokenTypes = map[yaml_token_type_t]TokenType{
+	yaml_STREAM_START_TOKEN:         TokenStreamStart,
+	yaml_STREAM_END_TOKEN:           TokenStreamEnd,
+	yaml_VERSION_DIRECTIVE_TOKEN:    TokenVersionDirective,
+	yaml_TAG_DIRECTIVE_TOKEN:        TokenTagDirective,
+	yaml_DOCUMENT_START_TOKEN:       TokenDocumentStart,
+	yaml_DOCUMENT_END_TOKEN:         TokenDocumentEnd,
+	yaml_BLOCK_SEQUENCE_START_TOKEN: TokenBlockSequenceStart,
+	yaml_BLOCK_MAPPING_START_TOKEN:  TokenBlockMappingStart,
+	yaml_BLOCK_END_TOKEN:            TokenBlockEnd,
+	yaml_FLOW_SEQUENCE_START_TOKEN:  TokenFlowSequenceStart,
+	yaml_FLOW_SEQUENCE_END_TOKEN:    TokenFlowSequenceEnd,
+	yaml_FLOW_MAPPING_START_TOKEN:   TokenFlowMappingStart,
+	yaml_FLOW_MAPPING_END_TOKEN:     TokenFlowMappingEnd,
+	yaml_BLOCK_ENTRY_TOKEN:          TokenBlockEntry,
+	yaml_FLOW_ENTRY_TOKEN:           TokenFlowEntry,
+	yaml_KEY_TOKEN:                  TokenKey,
+	yaml_VALUE_TOKEN:                TokenValue,
+	yaml_ALIAS_TOKEN:                TokenAlias,
+	yaml_ANCHOR_TOKEN:               TokenAnchor,
+	yaml_TAG_TOKEN:                  TokenTag,
+	yaml_SCALAR_TOKEN:               TokenScalar,
+}
+
+// Token represents a YAML scanner token. Value holds the scalar text, the
+// alias or anchor name, or the handle of a tag or %TAG directive; Suffix
+// holds a tag's suffix and Prefix a %TAG directive's prefix. Indicator
+// tokens such as KEY, VALUE and BLOCK-ENTRY only carry their marks.
+type Token struct {
+	Type         TokenType
+	Value        string
+	Suffix       string
+	Prefix       string
+	Style        EventStyle
+	VersionMajor int
+	VersionMinor int
+	StartMark    Mark
+	EndMark      Mark
+}
+
+// Scanner provides access to the token layer below the event stream: the
+// indicators, keys, values and flow punctuation that events abstract away
+type Scanner struct {
+	p *Parser
+}
+
+// NewScanner creates a new YAML scanner reading from the given reader
+func NewScanner(reader io.Reader) (*Scanner, error) {
+	p, err := NewParser(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Scanner{p: p}, nil
+}
+
+// NewScannerFromBytes creates a new YAML scanner reading directly from the
+// given byte slice, so token marks carry byte offsets
+func NewScannerFromBytes(input []byte) (*Scanner, error) {
+	p, err := NewParserFromBytes(input)
+	if err != nil {
+		return nil, err
+	}
+	return &Scanner{p: p}, nil
+}
+
+// NextToken returns the next token in the YAML stream, or nil after the
+// STREAM-END token
+func (s *Scanner) NextToken() (*Token, error) {
+	p := s.p
+	if p.done {
+		return nil, nil
+	}
+
+	var yamlToken yaml_token_t
+	if !yaml_parser_scan(&p.parser, &yamlToken) {
+		if p.parser.error != yaml_NO_ERROR {
+			return nil, p.parseError()
+		}
+		p.done = true
+		return nil, nil
+	}
+
+	token := &Token{
+		Type:      tokenTypes[yamlToken.typ],
+		StartMark: p.mark(yamlToken.start_mark),
+		EndMark:   p.mark(yamlToken.end_mark),
+	}
+	switch yamlToken.typ {
+	case yaml_STREAM_END_TOKEN:
+		p.done = true
+	case yaml_VERSION_DIRECTIVE_TOKEN:
+		token.VersionMajor = int(yamlToken.major)
+		token.VersionMinor = int(yamlToken.minor)
+	case yaml_TAG_DIRECTIVE_TOKEN:
+		token.Value = string(yamlToken.value)
+		token.Prefix = string(yamlToken.prefix)
+	case yaml_TAG_TOKEN:
+		token.Value = string(yamlToken.value)
+		token.Suffix = string(yamlToken.suffix)
+	case yaml_ALIAS_TOKEN, yaml_ANCHOR_TOKEN:
+		token.Value = string(yamlToken.value)
+	case yaml_SCALAR_TOKEN:
+		token.Value = string(yamlToken.value)
+		token.Style = yaml_style_t(yamlToken.style)
+	}
+	return token, nil
+}
+
+// Close releases the scanner resources
+func (s *Scanner) Close() {
+	s.p.Close()
+}
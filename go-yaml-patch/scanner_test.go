@@ -0,0 +1,92 @@
+package yaml
+
+import "testing"
+
+func TestScanner(t *testing.T) {
+	const src = "key: &a valué\nlist: [1, *a]\n"
+	want := []struct {
+		typ        TokenType
+		value      string
+		line, col  int
+		endCol     int
+		byteOffset int
+	}{
+		{TokenStreamStart, "", 0, 0, 0, 0},
+		{TokenBlockMappingStart, "", 0, 0, 0, 0},
+		{TokenKey, "", 0, 0, 0, 0},
+		{TokenScalar, "key", 0, 0, 3, 0},
+		{TokenValue, "", 0, 3, 4, 3},
+		{TokenAnchor, "a", 0, 5, 7, 5},
+		{TokenScalar, "valué", 0, 8, 13, 8},
+		{TokenKey, "", 1, 0, 0, 15},
+		{TokenScalar, "list", 1, 0, 4, 15},
+		{TokenValue, "", 1, 4, 5, 19},
+		{TokenFlowSequenceStart, "", 1, 6, 7, 21},
+		{TokenScalar, "1", 1, 7, 8, 22},
+		{TokenFlowEntry, "", 1, 8, 9, 23},
+		{TokenAlias, "a", 1, 10, 12, 25},
+		{TokenFlowSequenceEnd, "", 1, 12, 13, 27},
+		{TokenBlockEnd, "", 2, 0, 0, 29},
+		{TokenStreamEnd, "", 2, 0, 0, 29},
+	}
+
+	s, err := NewScannerFromBytes([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for i, w := range want {
+		token, err := s.NextToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token == nil {
+			t.Fatalf("token %d: stream ended, want %v", i, w.typ)
+		}
+		if token.Type != w.typ || token.Value != w.value {
+			t.Errorf("token %d: got %v %q, want %v %q", i, token.Type, token.Value, w.typ, w.value)
+		}
+		start, end := token.StartMark, token.EndMark
+		if start.Line != w.line || start.Column != w.col || end.Column != w.endCol || start.ByteOffset != w.byteOffset {
+			t.Errorf("token %d (%v): got %d:%d-%d at byte %d, want %d:%d-%d at byte %d", i, token.Type,
+				start.Line, start.Column, end.Column, start.ByteOffset, w.line, w.col, w.endCol, w.byteOffset)
+		}
+	}
+	if token, err := s.NextToken(); token != nil || err != nil {
+		t.Errorf("got %v and error %v after STREAM-END", token, err)
+	}
+}
+
+func TestScannerDirectives(t *testing.T) {
+	s, err := NewScannerFromBytes([]byte("%YAML 1.1\n%TAG !e! tag:example.com,2000:\n--- !e!thing 'x'\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	var tokens []*Token
+	for {
+		token, err := s.NextToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token == nil {
+			break
+		}
+		tokens = append(tokens, token)
+	}
+	if len(tokens) != 7 {
+		t.Fatalf("got %d tokens, want 7", len(tokens))
+	}
+	if v := tokens[1]; v.Type != TokenVersionDirective || v.VersionMajor != 1 || v.VersionMinor != 1 {
+		t.Errorf("got %v %d.%d, want VERSION-DIRECTIVE 1.1", v.Type, v.VersionMajor, v.VersionMinor)
+	}
+	if d := tokens[2]; d.Type != TokenTagDirective || d.Value != "!e!" || d.Prefix != "tag:example.com,2000:" {
+		t.Errorf("got %v %q %q, want the %%TAG directive", d.Type, d.Value, d.Prefix)
+	}
+	if tag := tokens[4]; tag.Type != TokenTag || tag.Value != "!e!" || tag.Suffix != "thing" {
+		t.Errorf("got %v %q %q, want TAG !e! thing", tag.Type, tag.Value, tag.Suffix)
+	}
+	if scalar := tokens[5]; scalar.Type != TokenScalar || scalar.Style != StyleSingleQuoted {
+		t.Errorf("got %v with style %v, want a single-quoted SCALAR", scalar.Type, scalar.Style)
+	}
+}
@@ -0,0 +1,139 @@
+package yaml
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SortMappingKeys returns a copy of the given events with the entries of
+// every mapping ordered by key, for output that can be compared or diffed
+// reliably. Scalar keys are compared by value; entries with collection or
+// alias keys keep their order after them. Each entry moves as a whole,
+// with its value subtree and the comments of its events. A foot comment
+// that the parser reports on the event after an entry, as TailComment, is
+// moved with the entry it belongs to.
+//
+// Events that are not changed are shared with the input rather than
+// copied. Events outside documents are passed through. It is an error for
+// the new order to put an alias before its anchor, as sorting {b: &x 1,
+// a: *x} would.
+func SortMappingKeys(events []*Event) ([]*Event, error) {
+	out := make([]*Event, 0, len(events))
+	for i := 0; i < len(events); {
+		if !events[i].Type.IsContent() {
+			out = append(out, events[i])
+			i++
+			continue
+		}
+		node, next, err := sortNode(events, i)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, node...)
+		i = next
+	}
+	if err := checkAnchors(out); err != nil && checkAnchors(events) == nil {
+		return nil, fmt.Errorf("cannot sort mapping keys: %v", err)
+	}
+	return out, nil
+}
+
+// sortedEntry is a mapping entry being sorted
+type sortedEntry struct {
+	events []*Event // the key and value nodes
+	key    string
+	scalar bool   // whether the key is a scalar
+	tail   []byte // foot comment carried by the event after the entry
+}
+
+// sortNode returns the events of the node starting at events[i] with its
+// mappings sorted, and the index of the event following the node
+func sortNode(events []*Event, i int) ([]*Event, int, error) {
+	event := events[i]
+	switch event.Type {
+	case EventScalar, EventAlias:
+		return events[i : i+1], i + 1, nil
+	case EventSequenceStart, EventMappingStart:
+	default:
+		return nil, i, fmt.Errorf("unexpected %v event, expected a node", event.Type)
+	}
+
+	var children [][]*Event
+	for i++; ; {
+		if i >= len(events) {
+			return nil, i, fmt.Errorf("unexpected end of events")
+		}
+		if events[i].Type.IsCollectionEnd() {
+			break
+		}
+		child, next, err := sortNode(events, i)
+		if err != nil {
+			return nil, i, err
+		}
+		children = append(children, child)
+		i = next
+	}
+	end := events[i]
+
+	out := []*Event{event}
+	if event.Type == EventSequenceStart {
+		for _, child := range children {
+			out = append(out, child...)
+		}
+		return append(out, end), i + 1, nil
+	}
+	if len(children)%2 != 0 {
+		return nil, i, fmt.Errorf("%s: mapping has a key without a value", position(event.StartMark))
+	}
+
+	entries := make([]sortedEntry, len(children)/2)
+	for k := range entries {
+		key, value := children[2*k], children[2*k+1]
+		entry := sortedEntry{
+			events: append(append([]*Event{key[0].Clone()}, key[1:]...), value...),
+			key:    key[0].Value,
+			scalar: key[0].Type == EventScalar,
+		}
+		// The foot comment of the previous entry's value arrives on this key
+		if k > 0 {
+			entries[k-1].tail, entry.events[0].TailComment = entry.events[0].TailComment, nil
+		}
+		entries[k] = entry
+	}
+	if len(entries) == 0 {
+		return append(out, end), i + 1, nil
+	}
+	end = end.Clone()
+	entries[len(entries)-1].tail, end.TailComment = end.TailComment, nil
+
+	sort.SliceStable(entries, func(a, b int) bool {
+		ea, eb := entries[a], entries[b]
+		if ea.scalar != eb.scalar {
+			return ea.scalar
+		}
+		return ea.scalar && ea.key < eb.key
+	})
+
+	for k, entry := range entries {
+		if entry.tail != nil {
+			following := end
+			if k+1 < len(entries) {
+				following = entries[k+1].events[0]
+			}
+			following.TailComment = joinComments(entry.tail, following.TailComment)
+		}
+		out = append(out, entry.events...)
+	}
+	return append(out, end), i + 1, nil
+}
+
+// joinComments joins two comments into one, a line apart
+func joinComments(a, b []byte) []byte {
+	if len(b) == 0 {
+		return a
+	}
+	if len(a) == 0 {
+		return b
+	}
+	return append(append(append([]byte(nil), a...), '\n'), b...)
+}
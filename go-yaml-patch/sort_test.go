@@ -0,0 +1,31 @@
+package yaml
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSortMappingKeys(t *testing.T) {
+	sorted, err := SortMappingKeys(parseEvents(t, "c: 3\na: {z: 1, y: 2}\nb: [x]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, event := range sorted {
+		if event.IsKey {
+			keys = append(keys, event.Value)
+		}
+	}
+	if got, want := fmt.Sprint(keys), "[a y z b c]"; got != want {
+		t.Errorf("sorted keys are %s, want %s", got, want)
+	}
+}
+
+func TestSortMappingKeysAnchors(t *testing.T) {
+	if _, err := SortMappingKeys(parseEvents(t, "b: &x 1\na: *x\n")); err == nil {
+		t.Error("sorting an alias before its anchor gave no error")
+	}
+	if _, err := SortMappingKeys(parseEvents(t, "a: &x 1\nb: *x\n")); err != nil {
+		t.Errorf("sorting an anchor that stays first: %v", err)
+	}
+}
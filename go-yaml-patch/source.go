@@ -0,0 +1,253 @@
+package yaml
+
+import (
+	"bufio"
+	"bytes"
+	"unicode/utf8"
+)
+
+// SourceSpan returns the exact input bytes that produced the given event,
+// such as a scalar including its quotes. It is only available for parsers
+// created from a byte slice or string and returns nil for reader-based
+// parsers or events that do not belong to this parser's input.
+func (p *Parser) SourceSpan(event *Event) []byte {
+	start, end := event.StartMark.ByteOffset, event.EndMark.ByteOffset
+	if p.input == nil || start < 0 || start > end || end > len(p.input) {
+		return nil
+	}
+	return p.input[start:end]
+}
+
+// Offset returns how far the parser has got through its input in bytes,
+// for reporting progress against the size of the input. Parsers reading
+// from a byte slice or string report the end of the event most recently
+// returned by Next, and reader-based parsers the number of bytes read from
+// the reader so far, which runs ahead of the events by up to one buffer.
+func (p *Parser) Offset() int {
+	if p.reader != nil {
+		return p.reader.count
+	}
+	if p.last == nil || p.last.EndMark.ByteOffset < 0 {
+		return 0
+	}
+	return p.last.EndMark.ByteOffset
+}
+
+// ErrorContext returns the line of input holding the position of the last
+// error returned by Next, without its line break, and the character column
+// of that position within it, so that callers can print the line with a
+// pointer under the problem. ok is false when Next has not returned an
+// error or the parser does not read UTF-8 from a byte slice or string.
+func (p *Parser) ErrorContext() (line string, col int, ok bool) {
+	if !p.failed || p.input == nil || p.errOffset < 0 || p.errOffset > len(p.input) {
+		return "", 0, false
+	}
+	if p.parser.encoding == yaml_UTF16LE_ENCODING || p.parser.encoding == yaml_UTF16BE_ENCODING {
+		return "", 0, false
+	}
+	start := bytes.LastIndexAny(p.input[:p.errOffset], "\r\n") + 1
+	if start == 0 {
+		start = p.bomLength()
+	}
+	end := len(p.input)
+	if i := bytes.IndexAny(p.input[p.errOffset:], "\r\n"); i >= 0 {
+		end = p.errOffset + i
+	}
+	if start > p.errOffset {
+		start = p.errOffset
+	}
+	col = utf8.RuneCount(p.input[start:p.errOffset])
+	return string(p.input[start:end]), col, true
+}
+
+// setErrorOffset records the byte offset of an error returned by Next
+func (p *Parser) setErrorOffset(offset int) {
+	p.errOffset, p.failed = offset, true
+}
+
+// readScalarSource sets the fields of a scalar event that are read from
+// the input rather than reported by the underlying parser: RawValue, and
+// the chomping and indentation indicators of a block scalar, which the
+// scanner does not keep
+func (p *Parser) readScalarSource(event *Event) {
+	offset := p.scalarTokenOffset(event)
+	if offset < 0 {
+		return
+	}
+	if p.options.RawScalars && offset <= event.EndMark.ByteOffset {
+		end := event.EndMark.ByteOffset
+		event.RawValue = p.input[offset:end:end]
+	}
+
+	style := yaml_scalar_style_t(event.Style)
+	if style != yaml_LITERAL_SCALAR_STYLE && style != yaml_FOLDED_SCALAR_STYLE {
+		return
+	}
+	if p.parser.encoding == yaml_UTF16LE_ENCODING || p.parser.encoding == yaml_UTF16BE_ENCODING {
+		return
+	}
+	event.BlockChomping = ChompingClip
+	for i := offset + 1; i < offset+3 && i < len(p.input); i++ {
+		switch c := p.input[i]; {
+		case c == '-':
+			event.BlockChomping = ChompingStrip
+		case c == '+':
+			event.BlockChomping = ChompingKeep
+		case c >= '1' && c <= '9':
+			event.BlockIndent = int(c - '0')
+		default:
+			return
+		}
+	}
+}
+
+// scalarTokenOffset returns the byte offset of the scalar token that the
+// underlying parser has just consumed to produce the given event, or -1 if
+// it is not known. The event itself starts at the node's tag or anchor, if
+// it has one.
+func (p *Parser) scalarTokenOffset(event *Event) int {
+	if p.input == nil || event.StartMark.ByteOffset < 0 || p.parser.tokens_head == 0 {
+		return -1
+	}
+	token := &p.parser.tokens[p.parser.tokens_head-1]
+	if token.typ != yaml_SCALAR_TOKEN {
+		return -1
+	}
+	offset := event.StartMark.ByteOffset
+	for i := event.StartMark.Index; i < int(token.start_mark.index) && offset < len(p.input); i++ {
+		offset += p.charWidth(offset)
+	}
+	return offset
+}
+
+// isEmptyScalar reports whether the scalar event that the underlying parser
+// has just produced was inserted for a node without content rather than
+// read from a scalar token. A scalar read from the input always consumes
+// its token, which is then the last one before the token queue's head.
+func (p *Parser) isEmptyScalar() bool {
+	head := p.parser.tokens_head
+	return head == 0 || p.parser.tokens[head-1].typ != yaml_SCALAR_TOKEN
+}
+
+// markAt returns the mark of the given byte offset into the input of a
+// parser reading UTF-8 from memory
+func (p *Parser) markAt(offset int) Mark {
+	mark := Mark{ByteOffset: offset, Name: p.name}
+	var last byte
+	for i := p.bomLength(); i < offset; i++ {
+		c := p.input[i]
+		if c&0xc0 != 0x80 {
+			mark.Index++
+			mark.Column++
+		}
+		if c == '\r' || c == '\n' && last != '\r' {
+			mark.Line++
+		}
+		if c == '\r' || c == '\n' {
+			mark.Column = 0
+		}
+		last = c
+	}
+	return mark
+}
+
+// mark converts a mark from the underlying parser, adding its byte offset
+func (p *Parser) mark(m yaml_mark_t) Mark {
+	mark := Mark{
+		Index:      int(m.index),
+		Line:       int(m.line),
+		Column:     int(m.column),
+		ByteOffset: p.byteOffset(int(m.index)),
+		Name:       p.name,
+	}
+	if p.sources != nil {
+		mark.Source, mark.Line = p.sources.locate(mark.Index, mark.Line)
+	}
+	return mark
+}
+
+// charCursor remembers the last character index translated to a byte
+// offset, so that translating the steadily increasing marks of an event
+// stream only walks the input once
+type charCursor struct {
+	index  int
+	offset int
+}
+
+// byteOffset translates a character index from the underlying parser into a
+// byte offset into the in-memory input, or returns -1 if there is none
+func (p *Parser) byteOffset(index int) int {
+	if p.input == nil {
+		return -1
+	}
+	c := &p.cursor
+	if index < c.index || c.offset == 0 {
+		// The byte order mark is not counted as a character
+		*c = charCursor{offset: p.bomLength()}
+	}
+	for c.index < index && c.offset < len(p.input) {
+		c.offset += p.charWidth(c.offset)
+		c.index++
+	}
+	return c.offset
+}
+
+// bomLength returns the length of the byte order mark the input starts with
+func (p *Parser) bomLength() int {
+	if bom := byteOrderMark(p.parser.encoding); bytes.HasPrefix(p.input, bom) {
+		return len(bom)
+	}
+	return 0
+}
+
+// byteOrderMark returns the byte order mark of the given encoding
+func byteOrderMark(encoding yaml_encoding_t) []byte {
+	switch encoding {
+	case yaml_UTF16LE_ENCODING:
+		return []byte{0xff, 0xfe}
+	case yaml_UTF16BE_ENCODING:
+		return []byte{0xfe, 0xff}
+	default:
+		return []byte{0xef, 0xbb, 0xbf}
+	}
+}
+
+// bomReader drops the byte order mark at the start of its input. The
+// underlying parser only does this when it detects the encoding itself;
+// with a forced encoding its scanner skips the mark as a character and
+// counts it in the marks of the first line.
+type bomReader struct {
+	reader  *bufio.Reader
+	bom     []byte
+	checked bool
+}
+
+func (r *bomReader) Read(b []byte) (int, error) {
+	if !r.checked {
+		r.checked = true
+		if prefix, _ := r.reader.Peek(len(r.bom)); bytes.Equal(prefix, r.bom) {
+			r.reader.Discard(len(r.bom))
+		}
+	}
+	return r.reader.Read(b)
+}
+
+// charWidth returns the number of input bytes used by the character
+// starting at the given byte offset
+func (p *Parser) charWidth(offset int) int {
+	switch p.parser.encoding {
+	case yaml_UTF16LE_ENCODING, yaml_UTF16BE_ENCODING:
+		high := offset
+		if p.parser.encoding == yaml_UTF16LE_ENCODING {
+			high++
+		}
+		// A high surrogate starts a four byte pair
+		if high < len(p.input) && p.input[high]&0xfc == 0xd8 {
+			return 4
+		}
+		return 2
+	default:
+		_, width := utf8.DecodeRune(p.input[offset:])
+		return width
+	}
+}
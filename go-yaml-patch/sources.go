@@ -0,0 +1,142 @@
+package yaml
+
+import (
+	"io"
+	"sort"
+)
+
+// NewParserMulti creates a new YAML parser reading the given readers one
+// after the other as a single stream. A line break is inserted after any
+// reader whose input does not end with one, so that a document never runs
+// on from one reader into the next.
+//
+// Every Mark reports the index of the reader it falls in as Source, and
+// its Line counts from the start of that reader. Index still counts
+// characters from the start of the combined stream. Readers are located by
+// counting UTF-8 characters and line breaks as they are read, so the input
+// must be UTF-8.
+func NewParserMulti(readers []io.Reader, opts ...ParserOption) (*Parser, error) {
+	sources := &multiReader{readers: readers}
+	p, err := NewParser(sources, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.sources = sources
+	return p, nil
+}
+
+// multiReader concatenates the readers of a multi-source parser, recording
+// where in the character and line count of the stream each one ends
+type multiReader struct {
+	readers []io.Reader
+	current int
+
+	chars      int  // characters read so far
+	lines      int  // line breaks read so far
+	last, prev byte // last two bytes read
+	bom        int  // bytes of a leading byte order mark matched so far
+	bomDone    bool // the start of the stream is past any byte order mark
+	pending    bool // a line break must be inserted before the next read
+	started    bool // whether the current reader has returned any input
+
+	// ends and endLines hold the character index and line at which each
+	// finished reader ends
+	ends     []int
+	endLines []int
+}
+
+func (r *multiReader) Read(b []byte) (int, error) {
+	for {
+		if r.pending && len(b) > 0 {
+			r.pending = false
+			b[0] = '\n'
+			r.count(b[:1])
+			r.finish()
+			return 1, nil
+		}
+		if r.current >= len(r.readers) {
+			return 0, io.EOF
+		}
+
+		n, err := r.readers[r.current].Read(b)
+		r.count(b[:n])
+		if n > 0 {
+			r.started = true
+		}
+		if err == io.EOF {
+			if r.started && r.last != '\n' && r.last != '\r' {
+				if n == len(b) {
+					r.pending = true
+					return n, nil
+				}
+				b[n] = '\n'
+				r.count(b[n : n+1])
+				n++
+			}
+			r.finish()
+			err = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+// count adds the characters and line breaks in b to the totals. Line
+// breaks are those of the scanner: LF, CR, a CR LF pair, NEL, LS and PS.
+func (r *multiReader) count(b []byte) {
+	for _, c := range b {
+		if !r.bomDone {
+			// Skip a byte order mark at the very start, which the parser
+			// consumes without counting
+			if c == utf8BOM[r.bom] {
+				if r.bom++; r.bom == len(utf8BOM) {
+					r.bomDone = true
+				}
+				r.prev, r.last = r.last, c
+				continue
+			}
+			// The bytes matched so far start a character after all
+			if r.bom > 0 {
+				r.chars++
+			}
+			r.bomDone = true
+		}
+		if c&0xc0 != 0x80 {
+			r.chars++
+		}
+		switch {
+		case c == '\r' || c == '\n' && r.last != '\r':
+			r.lines++
+		case c == 0x85 && r.last == 0xc2:
+			r.lines++ // NEL
+		case (c == 0xa8 || c == 0xa9) && r.last == 0x80 && r.prev == 0xe2:
+			r.lines++ // LS or PS
+		}
+		r.prev, r.last = r.last, c
+	}
+}
+
+// utf8BOM is the UTF-8 encoding of the byte order mark
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// finish records the end of the current reader and moves on to the next
+func (r *multiReader) finish() {
+	r.ends = append(r.ends, r.chars)
+	r.endLines = append(r.endLines, r.lines)
+	r.current++
+	r.started = false
+}
+
+// locate returns the reader that the character at the given stream index
+// came from, and the given stream line relative to the start of it
+func (r *multiReader) locate(index, line int) (source, sourceLine int) {
+	source = sort.SearchInts(r.ends, index+1)
+	if source > 0 && source >= len(r.readers) {
+		source = len(r.readers) - 1
+	}
+	if source > 0 {
+		line -= r.endLines[source-1]
+	}
+	return source, line
+}
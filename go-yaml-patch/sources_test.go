@@ -0,0 +1,81 @@
+package yaml
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMultiReaderCount(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		chars  int
+		lines  int
+	}{
+		{"ascii", []string{"ab\ncd\n"}, 6, 2},
+		{"byte order mark", []string{"\ufeffab"}, 2, 0},
+		{"split byte order mark", []string{"\xef", "\xbb\xbf", "a"}, 1, 0},
+		{"lead byte of another character", []string{"ｱb"}, 2, 0},
+		{"split character", []string{"\xef\xbd", "\xb1b"}, 2, 0},
+		{"later byte order mark", []string{"a\ufeff"}, 2, 0},
+		{"crlf", []string{"a\r\nb\rc\n"}, 7, 3},
+		{"split crlf", []string{"a\r", "\nb"}, 4, 1},
+		{"nel", []string{"a\u0085b"}, 3, 1},
+		{"ls and ps", []string{"a\u2028b\u2029c"}, 5, 2},
+		{"split ls", []string{"a\xe2\x80", "\xa8b"}, 3, 1},
+		{"not a break", []string{"a‧b¥"}, 4, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r multiReader
+			for _, chunk := range tt.chunks {
+				r.count([]byte(chunk))
+			}
+			if r.chars != tt.chars || r.lines != tt.lines {
+				t.Errorf("counted %d characters and %d lines, want %d and %d",
+					r.chars, r.lines, tt.chars, tt.lines)
+			}
+		})
+	}
+}
+
+func TestParserMultiSources(t *testing.T) {
+	readers := []string{"ｱ: 1\u0085b: 2\n", "c: 3\n", "d: 4"}
+	want := map[string][2]int{ // source and line of each key
+		"ｱ": {0, 0}, "b": {0, 1}, "c": {1, 0}, "d": {2, 0},
+	}
+	var rs []io.Reader
+	for _, src := range readers {
+		rs = append(rs, strings.NewReader(src))
+	}
+	p, err := NewParserMulti(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	for {
+		event, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		if !event.IsKey {
+			continue
+		}
+		w, ok := want[event.Value]
+		if !ok {
+			t.Fatalf("unexpected key %q", event.Value)
+		}
+		delete(want, event.Value)
+		if got := [2]int{event.StartMark.Source, event.StartMark.Line}; got != w {
+			t.Errorf("key %q is in source %d on line %d, want source %d on line %d",
+				event.Value, got[0], got[1], w[0], w[1])
+		}
+	}
+	if len(want) != 0 {
+		t.Errorf("keys not found: %v", want)
+	}
+}
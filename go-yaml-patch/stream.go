@@ -0,0 +1,141 @@
+package yaml
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// EventResult carries a single event, or the error that ended the stream,
+// delivered by Parser.Stream
+type EventResult struct {
+	Event *Event
+	Err   error
+}
+
+// Stream runs the parse loop in a new goroutine and delivers each event on
+// the returned channel. The channel is closed after STREAM-END, after a
+// result carrying an error, or once ctx is cancelled. Reads are made as by
+// NextContext, so cancelling ctx ends the stream even while the goroutine
+// is waiting on the reader.
+//
+// The parser must not be used through its other methods while a stream is
+// running, and calling Stream again before the first stream has ended
+// delivers a single error result. Close cancels a running stream and waits
+// for its goroutine to exit before releasing the parser, so it is always
+// safe to call.
+func (p *Parser) Stream(ctx context.Context) <-chan EventResult {
+	if p.streamDone != nil {
+		select {
+		case <-p.streamDone:
+		default:
+			results := make(chan EventResult, 1)
+			results <- EventResult{Err: fmt.Errorf("parser is already streaming")}
+			close(results)
+			return results
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	results := make(chan EventResult)
+	done := make(chan struct{})
+	p.streamDone = done
+	p.stopStream = func() {
+		cancel()
+		<-done
+	}
+
+	go func() {
+		defer close(done)
+		defer close(results)
+		defer cancel()
+		for ctx.Err() == nil {
+			event, err := p.NextContext(ctx)
+			if event == nil && err == nil || ctx.Err() != nil {
+				return
+			}
+			select {
+			case results <- EventResult{Event: event, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+// NextContext returns the next event in the YAML stream like Next, but
+// gives up and returns ctx.Err() when ctx is cancelled.
+//
+// The context is checked each time the parser needs more input from a
+// reader; parsers reading from memory only check it before starting. A
+// read that is blocked in the underlying reader runs in its own goroutine,
+// so NextContext returns promptly on cancellation while that goroutine stays
+// blocked until the reader itself returns; its data is discarded. Once a
+// call has been cancelled the parser cannot continue, because the read that
+// was interrupted has lost its place in the input.
+func (p *Parser) NextContext(ctx context.Context) (*Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if p.reader != nil {
+		p.reader.ctx = ctx
+		defer func() { p.reader.ctx = nil }()
+	}
+
+	event, err := p.Next()
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return event, err
+}
+
+// contextReader wraps the parser input so that reads made on behalf of
+// NextContext can be abandoned when its context is cancelled
+type contextReader struct {
+	reader io.Reader
+	ctx    context.Context
+	count  int        // bytes handed to the parser so far
+	breaks lineBreaks // line breaks in those bytes
+}
+
+func (r *contextReader) Read(b []byte) (int, error) {
+	n, err := r.read(b)
+	r.count += n
+	r.breaks.add(b[:n])
+	return n, err
+}
+
+func (r *contextReader) read(b []byte) (int, error) {
+	if r.ctx == nil {
+		return r.reader.Read(b)
+	}
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	// Read into a private buffer so an abandoned read cannot write into
+	// the parser's buffer after we have returned
+	type readResult struct {
+		n   int
+		err error
+	}
+	buf := make([]byte, len(b))
+	done := make(chan readResult, 1)
+	go func() {
+		n, err := r.reader.Read(buf)
+		done <- readResult{n, err}
+	}()
+
+	select {
+	case result := <-done:
+		copy(b, buf[:result.n])
+		return result.n, result.err
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	}
+}
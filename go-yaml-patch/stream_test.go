@@ -0,0 +1,122 @@
+package yaml
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	src := "a: &x [1, 2]\nb: *x\n"
+	p, err := NewParser(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	var got []EventType
+	for result := range p.Stream(context.Background()) {
+		if result.Err != nil {
+			t.Fatalf("streaming %q: %v", src, result.Err)
+		}
+		got = append(got, result.Event.Type)
+	}
+	want := []EventType{
+		EventStreamStart, EventDocumentStart, EventMappingStart,
+		EventScalar, EventSequenceStart, EventScalar, EventScalar, EventSequenceEnd,
+		EventScalar, EventAlias,
+		EventMappingEnd, EventDocumentEnd, EventStreamEnd,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("event %d is %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	p, err := NewParser(strings.NewReader("a: [1, 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	var last EventResult
+	for result := range p.Stream(context.Background()) {
+		last = result
+	}
+	if _, ok := last.Err.(*ParseError); !ok {
+		t.Fatalf("last result is %+v, want a *ParseError", last)
+	}
+}
+
+// TestStreamCancelBlocked checks that a stream waiting on a reader that
+// never returns ends when its context is cancelled, and that Close then
+// returns
+func TestStreamCancelBlocked(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	p, err := NewParser(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	results := p.Stream(ctx)
+	cancel()
+
+	closed := make(chan struct{})
+	go func() {
+		for range results {
+		}
+		p.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end after its context was cancelled")
+	}
+}
+
+func TestStreamClose(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	p, err := NewParser(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Stream(context.Background())
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return while the stream was blocked reading")
+	}
+}
+
+func TestStreamTwice(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	p, err := NewParser(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	p.Stream(context.Background())
+
+	var results []EventResult
+	for result := range p.Stream(context.Background()) {
+		results = append(results, result)
+	}
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("second Stream delivered %+v, want a single error", results)
+	}
+}
@@ -0,0 +1,438 @@
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// collectionFrame records the state of an open sequence or mapping
+type collectionFrame struct {
+	start *Event // SEQUENCE-START or MAPPING-START
+	path  string
+	count int    // child nodes started so far
+	key   string // path segment of the current mapping key
+	size  int    // nodes in the expanded collection, when counting expansions
+
+	// keys maps the resolved scalar keys of a mapping to where they were
+	// first seen, when duplicate detection is enabled
+	keys map[string]Mark
+}
+
+// track updates the parser's structural state with the given event and
+// records that state on the event. It returns an error when the event
+// violates one of the checks enabled in the parser options.
+func (p *Parser) track(event *Event) error {
+	if event.Type.IsCollectionEnd() {
+		top := p.stack[len(p.stack)-1]
+		p.stack = p.stack[:len(p.stack)-1]
+		event.Depth = len(p.stack)
+		event.Path = top.path
+		if top.start.Anchor != "" {
+			p.anchorEnds[top.start] = p.retain(event)
+		}
+		if p.options.MaxAliasExpansions > 0 {
+			p.addSize(top.start.Anchor, top.size)
+		}
+		return nil
+	}
+
+	event.Depth = len(p.stack)
+	if event.Type == EventDocumentStart {
+		p.anchors = nil
+		p.anchorEnds = nil
+		p.anchorSizes = nil
+		p.expansions = 0
+		p.styles = StyleStats{}
+	}
+	if !event.Type.IsContent() {
+		return nil
+	}
+	if len(p.stack) > 0 {
+		parent := &p.stack[len(p.stack)-1]
+		switch {
+		case parent.start.Type == EventSequenceStart:
+			event.Path = parent.path + "/" + strconv.Itoa(parent.count)
+		case parent.count%2 == 0:
+			event.InMapping, event.IsKey = true, true
+			event.IsMergeKey = isMergeKey(event)
+			parent.key = keySegment(event)
+			event.Path = parent.path + "/" + parent.key
+			if p.options.DetectDuplicateKeys && event.Type == EventScalar {
+				if err := parent.addKey(event); err != nil {
+					return err
+				}
+			}
+		default:
+			event.InMapping = true
+			event.Path = parent.path + "/" + parent.key
+		}
+		parent.count++
+	}
+
+	p.styles.add(event)
+	if p.options.MaxAliasExpansions > 0 {
+		if err := p.countExpansion(event); err != nil {
+			return err
+		}
+	}
+
+	if event.Type == EventAlias {
+		if p.options.ValidateAliases && p.anchors[event.Anchor] == nil {
+			return &UndefinedAnchorError{
+				Anchor: event.Anchor,
+				Path:   event.Path,
+				Mark:   event.StartMark,
+			}
+		}
+		if p.options.DetectAliasCycles {
+			if err := p.checkCycle(event); err != nil {
+				return err
+			}
+		}
+	}
+
+	var retained *Event
+	if event.Anchor != "" && event.Type != EventAlias {
+		retained = p.retain(event)
+		if p.anchors == nil {
+			p.anchors = make(map[string]*Event)
+		}
+		p.anchors[event.Anchor] = retained
+		if event.Type.IsCollectionStart() {
+			if p.anchorEnds == nil {
+				p.anchorEnds = make(map[*Event]*Event)
+			}
+			p.anchorEnds[retained] = nil
+		}
+	}
+
+	if event.Type.IsCollectionStart() {
+		if p.options.MaxDepth > 0 && len(p.stack) >= p.options.MaxDepth {
+			return &DepthLimitError{
+				Limit: p.options.MaxDepth,
+				Path:  event.Path,
+				Mark:  event.StartMark,
+			}
+		}
+		if retained == nil {
+			retained = p.retain(event)
+		}
+		p.stack = append(p.stack, collectionFrame{start: retained, path: event.Path, size: 1})
+	}
+	return nil
+}
+
+// retain returns the event for the parser to keep a reference to: the
+// event itself, or a copy while NextInto parses into the caller's Event
+func (p *Parser) retain(event *Event) *Event {
+	if p.borrowed {
+		return event.Clone()
+	}
+	return event
+}
+
+// countExpansion accounts for the nodes the given content event adds to
+// the expanded document, returning an ExpansionLimitError once its aliases
+// have added more than MaxAliasExpansions nodes. Collections are accounted
+// for when they end, once their size is known.
+func (p *Parser) countExpansion(event *Event) error {
+	switch event.Type {
+	case EventScalar:
+		p.addSize(event.Anchor, 1)
+	case EventAlias:
+		// The anchor of a collection that is still open has no size yet;
+		// such a cycle is left to DetectAliasCycles
+		size, ok := p.anchorSizes[event.Anchor]
+		if !ok {
+			size = 1
+		}
+		p.expansions += size
+		if p.expansions > p.options.MaxAliasExpansions {
+			return &ExpansionLimitError{
+				Limit:  p.options.MaxAliasExpansions,
+				Anchor: event.Anchor,
+				Path:   event.Path,
+				Mark:   event.StartMark,
+			}
+		}
+		p.addSize("", size)
+	}
+	return nil
+}
+
+// addSize records the expanded size of a completed node, adding it to the
+// enclosing collection and to the node's anchor, if any
+func (p *Parser) addSize(anchor string, size int) {
+	if len(p.stack) > 0 {
+		p.stack[len(p.stack)-1].size += size
+	}
+	if anchor != "" {
+		if p.anchorSizes == nil {
+			p.anchorSizes = make(map[string]int)
+		}
+		p.anchorSizes[anchor] = size
+	}
+}
+
+// checkLimits counts the given event and returns a LimitExceededError if
+// it goes past the MaxEvents or MaxBytes option
+func (p *Parser) checkLimits(event *Event) error {
+	p.events++
+	if limit := p.options.MaxEvents; limit > 0 && p.events > limit {
+		return &LimitExceededError{Limit: limit, Unit: "events", Mark: event.StartMark}
+	}
+	consumed := event.EndMark.ByteOffset
+	if consumed < 0 {
+		consumed = event.EndMark.Index
+	}
+	if limit := p.options.MaxBytes; limit > 0 && consumed > limit {
+		return &LimitExceededError{Limit: limit, Unit: "bytes", Mark: event.StartMark}
+	}
+	return nil
+}
+
+// checkScalarSize returns a ScalarTooLargeError if the value of the given
+// scalar event goes past the MaxScalarBytes option
+func (p *Parser) checkScalarSize(yamlEvent *yaml_event_t) *ScalarTooLargeError {
+	limit := p.options.MaxScalarBytes
+	if limit <= 0 || len(yamlEvent.value) <= limit {
+		return nil
+	}
+	// Report the start of the value itself rather than of its tag or anchor
+	start := yamlEvent.start_mark
+	if !p.isEmptyScalar() {
+		start = p.parser.tokens[p.parser.tokens_head-1].start_mark
+	}
+	return &ScalarTooLargeError{Limit: limit, Size: len(yamlEvent.value), Mark: p.mark(start)}
+}
+
+// checkCycle returns a CycleError if the given alias refers to the anchor
+// of a collection that is still open, which would make the node contain
+// itself
+func (p *Parser) checkCycle(alias *Event) error {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if start := p.stack[i].start; start.Anchor == alias.Anchor {
+			return &CycleError{
+				Anchor:     alias.Anchor,
+				Path:       alias.Path,
+				AnchorMark: start.StartMark,
+				Mark:       alias.StartMark,
+			}
+		}
+	}
+	return nil
+}
+
+// addKey records a scalar mapping key, returning a DuplicateKeyError if an
+// equal key was already seen in the mapping
+func (f *collectionFrame) addKey(event *Event) error {
+	key := resolvedKey(event)
+	if first, ok := f.keys[key]; ok {
+		return &DuplicateKeyError{
+			Key:       event.Value,
+			Path:      event.Path,
+			FirstMark: first,
+			Mark:      event.StartMark,
+		}
+	}
+	if f.keys == nil {
+		f.keys = make(map[string]Mark)
+	}
+	f.keys[key] = event.StartMark
+	return nil
+}
+
+// resolvedKey returns a string identifying a scalar key by its resolved tag
+// and value, so that "0x1A" and "26" compare equal but "1" and 1 do not
+func resolvedKey(event *Event) string {
+	tag := event.ResolvedTag()
+	value := event.Value
+	switch tag {
+	case yaml_NULL_TAG:
+		value = ""
+	case yaml_BOOL_TAG:
+		b, _ := event.AsBool()
+		value = strconv.FormatBool(b)
+	case yaml_INT_TAG:
+		if i, ok := event.AsInt(); ok {
+			value = strconv.FormatInt(i, 10)
+		} else if u, ok := event.AsUint(); ok {
+			value = strconv.FormatUint(u, 10)
+		}
+	case yaml_FLOAT_TAG:
+		if f, ok := event.AsFloat(); ok {
+			value = strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	}
+	return tag + " " + value
+}
+
+// pathEscaper escapes a JSON Pointer reference token
+var pathEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// keySegment returns the path segment for the mapping entry introduced by
+// the given key event
+func keySegment(event *Event) string {
+	switch event.Type {
+	case EventScalar:
+		return pathEscaper.Replace(event.Value)
+	case EventAlias:
+		return "*" + pathEscaper.Replace(event.Anchor)
+	default:
+		return "?"
+	}
+}
+
+// SkipSubtree consumes the rest of the collection whose SEQUENCE-START or
+// MAPPING-START event was the last one returned by Next, up to and
+// including its end event. It does nothing if the last event returned was
+// not the start of a collection.
+func (p *Parser) SkipSubtree() error {
+	start := p.last
+	if start == nil || !start.Type.IsCollectionStart() {
+		return nil
+	}
+	for {
+		event, err := p.Next()
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			return fmt.Errorf("unexpected end of events")
+		}
+		if event.Type.IsCollectionEnd() && event.Depth == start.Depth {
+			return nil
+		}
+	}
+}
+
+// FindKey walks forward from the next node in the stream, which is the
+// root node of the next document if the stream or a document is just
+// starting, following the given path of mapping keys. Sequences are only
+// entered by a path element that is a decimal index.
+//
+// When the path exists FindKey returns the first event of the value it
+// leads to without consuming it, so the next call to Next, SkipSubtree
+// after Next, or DecodeValue reads that value. Otherwise it returns a
+// KeyNotFoundError, leaving the parser after the collection that lacked
+// the key.
+func (p *Parser) FindKey(path ...string) (*Event, error) {
+	event, err := p.Peek()
+	for err == nil && event != nil &&
+		(event.Type == EventStreamStart || event.Type == EventDocumentStart) {
+		p.Next()
+		event, err = p.Peek()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pointer := ""
+	for _, key := range path {
+		pointer += "/" + pathEscaper.Replace(key)
+		if event != nil && event.Type.IsContent() {
+			event, err = p.findChild(key)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if event == nil || !event.Type.IsContent() {
+			return nil, &KeyNotFoundError{Path: pointer}
+		}
+	}
+	return event, nil
+}
+
+// findChild consumes the start of the collection that is the next node in
+// the stream and reads forward to the entry named by key, returning the
+// first event of its value without consuming it. It returns nil if the node
+// is not a collection or has no such entry.
+func (p *Parser) findChild(key string) (*Event, error) {
+	node, err := p.Next()
+	if err != nil {
+		return nil, err
+	}
+	switch node.Type {
+	case EventMappingStart:
+		for {
+			k, err := p.nextInSubtree()
+			if err != nil || k.Type == EventMappingEnd {
+				return nil, err
+			}
+			if k.Type == EventScalar && k.Value == key {
+				return p.Peek()
+			}
+			if err := p.SkipSubtree(); err != nil {
+				return nil, err
+			}
+			if _, err := p.nextInSubtree(); err != nil {
+				return nil, err
+			}
+			if err := p.SkipSubtree(); err != nil {
+				return nil, err
+			}
+		}
+	case EventSequenceStart:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 {
+			return nil, p.SkipSubtree()
+		}
+		for i := 0; ; i++ {
+			item, err := p.Peek()
+			if err != nil || item == nil {
+				return nil, err
+			}
+			if i == index && item.Type != EventSequenceEnd {
+				return item, nil
+			}
+			p.Next()
+			if item.Type == EventSequenceEnd {
+				return nil, nil
+			}
+			if err := p.SkipSubtree(); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, nil
+	}
+}
+
+// nextInSubtree returns the next event, failing if the stream ends
+func (p *Parser) nextInSubtree() (*Event, error) {
+	event, err := p.Next()
+	if err == nil && event == nil {
+		err = fmt.Errorf("unexpected end of events")
+	}
+	return event, err
+}
+
+// Anchors returns the anchors defined so far in the current document,
+// mapped to the scalar or collection start event that defined each one.
+// When an anchor name is reused the latest definition wins, as it does for
+// aliases. The table is cleared at every DOCUMENT-START.
+func (p *Parser) Anchors() map[string]*Event {
+	anchors := make(map[string]*Event, len(p.anchors))
+	for name, event := range p.anchors {
+		anchors[name] = event
+	}
+	return anchors
+}
+
+// MatchingEnd returns the end event matching an anchored collection start
+// event of the current document, or nil if the collection has not been
+// closed yet. The start event may be the one Next returned, or a copy of
+// it, such as the caller's event under NextInto or a Clone kept from it,
+// which is matched by its anchor and start mark.
+func (p *Parser) MatchingEnd(start *Event) *Event {
+	if end, ok := p.anchorEnds[start]; ok {
+		return end
+	}
+	for retained, end := range p.anchorEnds {
+		if retained.Anchor == start.Anchor && retained.StartMark == start.StartMark {
+			return end
+		}
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// parseErr parses the given stream to the end and returns the error that
+// stopped it, if any
+func parseErr(t testing.TB, src string, opts ...ParserOption) error {
+	t.Helper()
+	p, err := NewParserFromString(src, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	for {
+		event, err := p.Next()
+		if err != nil || event == nil {
+			return err
+		}
+	}
+}
+
+func TestUndefinedAnchor(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		anchor string
+		path   string
+		line   int
+	}{
+		{"root", "*a\n", "a", "", 0},
+		{"mapping value", "a: 1\nb: *missing\n", "missing", "/b", 1},
+		{"sequence item", "list:\n- &x 1\n- *y\n", "y", "/list/1", 2},
+		{"forward reference", "a: *x\nb: &x 1\n", "x", "/a", 0},
+		{"mapping key", "{*k : v}\n", "k", "/*k", 0},
+		{"previous document", "--- &a 1\n--- [*a]\n", "a", "/0", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseErr(t, tt.src, WithAliasValidation())
+			e, ok := err.(*UndefinedAnchorError)
+			if !ok {
+				t.Fatalf("got error %v, want an UndefinedAnchorError", err)
+			}
+			if e.Anchor != tt.anchor || e.Path != tt.path || e.Mark.Line != tt.line {
+				t.Errorf("got anchor %q at %q on line %d, want %q at %q on line %d",
+					e.Anchor, e.Path, e.Mark.Line, tt.anchor, tt.path, tt.line)
+			}
+		})
+	}
+}
+
+func TestDefinedAnchor(t *testing.T) {
+	srcs := []string{
+		"a: &x 1\nb: *x\n",
+		"- &x [1, 2]\n- *x\n- *x\n",
+		"&k key: v\nother: *k\n",
+	}
+	for _, src := range srcs {
+		if err := parseErr(t, src, WithAliasValidation()); err != nil {
+			t.Errorf("parsing %q: %v", src, err)
+		}
+	}
+}
+
+func TestAliasCycle(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		path       string
+		anchorLine int
+		line       int
+	}{
+		{"mapping", "a: &x\n  b: *x\n", "/a/b", 0, 1},
+		{"flow sequence", "&s [1, *s]\n", "/1", 0, 0},
+		{"nested", "top: &t\n  list:\n  - {inner: *t}\n", "/top/list/0/inner", 0, 2},
+		{"key", "&m {*m : v}\n", "/*m", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseErr(t, tt.src, WithAliasCycleDetection())
+			e, ok := err.(*CycleError)
+			if !ok {
+				t.Fatalf("got error %v, want a CycleError", err)
+			}
+			if e.Path != tt.path || e.AnchorMark.Line != tt.anchorLine || e.Mark.Line != tt.line {
+				t.Errorf("got a cycle at %q on line %d, anchored on line %d, want %q on line %d, anchored on line %d",
+					e.Path, e.Mark.Line, e.AnchorMark.Line, tt.path, tt.line, tt.anchorLine)
+			}
+		})
+	}
+
+	// Aliases to closed collections are not cycles
+	for _, src := range []string{"a: &x {b: 1}\nc: *x\n", "- &x [1]\n- [*x, *x]\n"} {
+		if err := parseErr(t, src, WithAliasCycleDetection()); err != nil {
+			t.Errorf("parsing %q: %v", src, err)
+		}
+	}
+}
+
+// laughs returns a "billion laughs" document, the alias expansion attack
+// behind CVE-2003-1564 and the YAML parser CVEs that followed it, with the
+// given number of levels of ten aliases each
+func laughs(levels int) string {
+	var b strings.Builder
+	b.WriteString("l0: &l0 [lol, lol, lol, lol, lol, lol, lol, lol, lol, lol]\n")
+	for i := 1; i <= levels; i++ {
+		fmt.Fprintf(&b, "l%d: &l%d [", i, i)
+		for j := 0; j < 10; j++ {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "*l%d", i-1)
+		}
+		b.WriteString("]\n")
+	}
+	return b.String()
+}
+
+func TestExpansionLimit(t *testing.T) {
+	// Each alias of l0 adds its 11 nodes and each alias of l1 its 111, so
+	// the ninth alias in l2 takes the count past 1000
+	err := parseErr(t, laughs(8), WithMaxAliasExpansions(1000))
+	e, ok := err.(*ExpansionLimitError)
+	if !ok {
+		t.Fatalf("got error %v, want an ExpansionLimitError", err)
+	}
+	if e.Limit != 1000 || e.Anchor != "l1" || e.Path != "/l2/8" || e.Mark.Line != 2 {
+		t.Errorf("got limit %d passed by *%s at %q on line %d, want 1000 by *l1 at %q on line 2",
+			e.Limit, e.Anchor, e.Path, e.Mark.Line, "/l2/8")
+	}
+
+	if err := parseErr(t, laughs(1), WithMaxAliasExpansions(1000)); err != nil {
+		t.Errorf("one level, adding 110 nodes, is over the limit: %v", err)
+	}
+	if err := parseErr(t, laughs(8)); err != nil {
+		t.Errorf("without a limit: %v", err)
+	}
+}
+
+func TestMatchingEndNextInto(t *testing.T) {
+	p, err := NewParserFromString("a: &x [1, {b: 2}]\nc: 3\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	var event Event
+	var start *Event
+	for {
+		ok, err := p.NextInto(&event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		if event.Anchor == "x" {
+			start = event.Clone()
+		}
+		if event.Type == EventScalar && event.Value == "c" {
+			break
+		}
+	}
+	if start == nil {
+		t.Fatal("anchored sequence not found")
+	}
+	for name, e := range map[string]*Event{"a clone": start, "the retained copy": p.Anchors()["x"]} {
+		end := p.MatchingEnd(e)
+		if end == nil || end.Type != EventSequenceEnd || end.Path != "/a" {
+			t.Errorf("end matching %s is %v, want the SEQUENCE-END at /a", name, end)
+		}
+	}
+}
+
+// nextEvent returns the next event, failing the test at an error or the
+// end of the stream
+func nextEvent(t *testing.T, p *Parser) *Event {
+	t.Helper()
+	event, err := p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event == nil {
+		t.Fatal("unexpected end of events")
+	}
+	return event
+}
+
+func TestSkipSubtree(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		start string // path of the collection to skip
+		next  string // value of the scalar after it
+	}{
+		{"nested mapping", "a: {b: [1, 2], c: {d: 3}}\ne: 4\n", "/a", "e"},
+		{"nested sequences", "- [[1], [2]]\n- x\n", "/0", "x"},
+		{"block collections", "a:\n  b:\n  - 1\n  - c: 2\nd: 3\n", "/a", "d"},
+		{"empty", "a: []\nb: 1\n", "/a", "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewParserFromString(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+			for {
+				if event := nextEvent(t, p); event.Type.IsCollectionStart() && event.Path == tt.start {
+					break
+				}
+			}
+			if err := p.SkipSubtree(); err != nil {
+				t.Fatal(err)
+			}
+			if event := nextEvent(t, p); event.Type != EventScalar || event.Value != tt.next {
+				t.Errorf("got %v %q after the skip, want the scalar %q", event.Type, event.Value, tt.next)
+			}
+		})
+	}
+
+	p, err := NewParserFromString("a: [1]\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	for _, want := range []EventType{EventStreamStart, EventDocumentStart, EventMappingStart} {
+		if err := p.SkipSubtree(); err != nil {
+			t.Fatal(err)
+		}
+		if event := nextEvent(t, p); event.Type != want {
+			t.Fatalf("got %v, want %v", event.Type, want)
+		}
+	}
+	if err := p.SkipSubtree(); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(t, p); event.Type != EventDocumentEnd {
+		t.Errorf("got %v after skipping the root, want DOCUMENT-END", event.Type)
+	}
+
+	p, err = NewParserFromString("a: [1, {b: 2\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	nextEvent(t, p)
+	nextEvent(t, p)
+	nextEvent(t, p)
+	if err := p.SkipSubtree(); err == nil {
+		t.Error("skipping an unclosed mapping gave no error")
+	}
+}
+
+func TestFindKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		path  []string
+		typ   EventType
+		value string
+	}{
+		{"nested", "a:\n  b:\n    c: 42\n", []string{"a", "b", "c"}, EventScalar, "42"},
+		{"sequence index", "list:\n- x\n- {name: y}\n", []string{"list", "1", "name"}, EventScalar, "y"},
+		{"root sequence", "- a\n- b\n", []string{"1"}, EventScalar, "b"},
+		{"collection value", "a: 1\nb: [2]\n", []string{"b"}, EventSequenceStart, ""},
+		{"repeated key", "a: 1\na: 2\n", []string{"a"}, EventScalar, "1"},
+		{"same key nested earlier", "skip: {a: 1, b: [a]}\na: 2\n", []string{"a"}, EventScalar, "2"},
+		{"after a complex key", "? [k]\n: v\na: 3\n", []string{"a"}, EventScalar, "3"},
+		{"explicit document", "--- {a: {b: c}}\n", []string{"a", "b"}, EventScalar, "c"},
+		{"empty path", "a: 1\n", nil, EventMappingStart, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewParserFromString(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+			event, err := p.FindKey(tt.path...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Type != tt.typ || event.Value != tt.value {
+				t.Fatalf("found %v %q, want %v %q", event.Type, event.Value, tt.typ, tt.value)
+			}
+			if next := nextEvent(t, p); next != event {
+				t.Errorf("Next returned %v %q, not the event found", next.Type, next.Value)
+			}
+		})
+	}
+
+	missing := []struct {
+		name    string
+		src     string
+		path    []string
+		pointer string
+	}{
+		{"missing key", "a: {b: 1}\n", []string{"a", "c"}, "/a/c"},
+		{"index past the end", "a: [1]\n", []string{"a", "1"}, "/a/1"},
+		{"key in a sequence", "a: [1]\n", []string{"a", "b"}, "/a/b"},
+		{"key in a scalar", "a: 1\n", []string{"a", "b", "c"}, "/a/b"},
+		{"escaped", "a/b: 1\n", []string{"a~b"}, "/a~0b"},
+	}
+	for _, tt := range missing {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewParserFromString(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+			_, err = p.FindKey(tt.path...)
+			if notFound, ok := err.(*KeyNotFoundError); !ok || notFound.Path != tt.pointer {
+				t.Errorf("got error %v, want a KeyNotFoundError for %q", err, tt.pointer)
+			}
+		})
+	}
+
+	// A missing key leaves the parser after the mapping that lacked it
+	p, err := NewParserFromString("a: {b: 1}\nnext: 2\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if _, err := p.FindKey("a", "c"); err == nil {
+		t.Fatal("got no error")
+	}
+	if event := nextEvent(t, p); event.Type != EventScalar || event.Value != "next" {
+		t.Errorf("got %v %q after the missing key, want the scalar next", event.Type, event.Value)
+	}
+}
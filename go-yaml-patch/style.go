@@ -0,0 +1,69 @@
+package yaml
+
+// StyleStats counts the styles of the nodes in a document, for linters that
+// enforce a consistent style
+type StyleStats struct {
+	BlockSequences int
+	FlowSequences  int
+	BlockMappings  int
+	FlowMappings   int
+
+	PlainScalars        int
+	SingleQuotedScalars int
+	DoubleQuotedScalars int
+	LiteralScalars      int
+	FoldedScalars       int
+}
+
+// Mixed reports whether the document has both block and flow collections
+func (s StyleStats) Mixed() bool {
+	return s.BlockSequences+s.BlockMappings > 0 && s.FlowSequences+s.FlowMappings > 0
+}
+
+// add counts the style of the given event
+func (s *StyleStats) add(event *Event) {
+	flow := event.Style == StyleFlow
+	switch event.Type {
+	case EventSequenceStart:
+		if flow {
+			s.FlowSequences++
+		} else {
+			s.BlockSequences++
+		}
+	case EventMappingStart:
+		if flow {
+			s.FlowMappings++
+		} else {
+			s.BlockMappings++
+		}
+	case EventScalar:
+		switch yaml_scalar_style_t(event.Style) {
+		case yaml_SINGLE_QUOTED_SCALAR_STYLE:
+			s.SingleQuotedScalars++
+		case yaml_DOUBLE_QUOTED_SCALAR_STYLE:
+			s.DoubleQuotedScalars++
+		case yaml_LITERAL_SCALAR_STYLE:
+			s.LiteralScalars++
+		case yaml_FOLDED_SCALAR_STYLE:
+			s.FoldedScalars++
+		default:
+			s.PlainScalars++
+		}
+	}
+}
+
+// StyleStats returns the style counts of the current document, covering
+// every event parsed so far, including a peeked one. The counts are reset
+// at every DOCUMENT-START.
+func (p *Parser) StyleStats() StyleStats {
+	return p.styles
+}
+
+// OnEvent registers a function that the parser calls with every event it
+// produces, once its fields are complete and before it is returned, so
+// callers can observe the stream without writing their own loop around
+// Next. The function must not call the parser or modify the event. A nil
+// function removes the hook, as does Reset.
+func (p *Parser) OnEvent(hook func(*Event)) {
+	p.onEvent = hook
+}
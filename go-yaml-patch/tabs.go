@@ -0,0 +1,54 @@
+package yaml
+
+// tabCheck scans the input of a parser for the RejectTabs option, keeping
+// its place between events
+type tabCheck struct {
+	offset  int  // input checked so far
+	started bool // offset has been moved past the byte order mark
+	indent  bool // the scan is in the leading whitespace of a line
+	tab     int  // offset of the first tab in that whitespace, or -1
+}
+
+// checkTabs returns a TabIndentationError if the input up to the end of the
+// given event has a tab in the leading whitespace of a line with content.
+// Tabs before a comment or on a blank line are allowed, and the content of
+// quoted and block scalars, where a tab is part of the value, is skipped.
+func (p *Parser) checkTabs(event *Event) *TabIndentationError {
+	if p.input == nil || p.parser.encoding == yaml_UTF16LE_ENCODING || p.parser.encoding == yaml_UTF16BE_ENCODING {
+		return nil
+	}
+	t := &p.tabs
+	if !t.started {
+		*t = tabCheck{offset: p.bomLength(), started: true, indent: true, tab: -1}
+	}
+
+	end := event.EndMark.ByteOffset
+	content := end
+	if event.Type == EventScalar && !event.IsPlain() {
+		if offset := p.scalarTokenOffset(event); offset >= 0 {
+			content = offset
+		}
+	}
+	for ; t.offset < content && t.offset < len(p.input); t.offset++ {
+		switch c := p.input[t.offset]; {
+		case c == '\n' || c == '\r':
+			t.indent, t.tab = true, -1
+		case !t.indent || c == ' ':
+		case c == '\t':
+			if t.tab < 0 {
+				t.tab = t.offset
+			}
+		default:
+			if t.tab >= 0 && c != '#' {
+				return &TabIndentationError{Mark: p.markAt(t.tab)}
+			}
+			t.indent = false
+		}
+	}
+	if content < end && t.offset < end {
+		// The scalar starts with its quote or block indicator, so the scan
+		// resumes after it in the middle of its last line
+		t.offset, t.indent, t.tab = end, false, -1
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package yaml
+
+import "testing"
+
+func TestRejectTabs(t *testing.T) {
+	rejected := []struct {
+		name       string
+		src        string
+		line, col  int
+		byteOffset int
+	}{
+		{"flow item", "a: [1,\n\t2]\n", 1, 0, 7},
+		{"plain continuation", "a: b\n \tc\n", 1, 1, 6},
+		{"flow mapping key", "{a: 1,\n\tb: 2}\n", 1, 0, 7},
+		{"byte order mark", "\ufeffa: [1,\n\t2]\n", 1, 0, 10},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := parseErr(t, tt.src); err != nil {
+				t.Fatalf("without RejectTabs: %v", err)
+			}
+			err := parseErr(t, tt.src, WithRejectTabs())
+			tabErr, ok := err.(*TabIndentationError)
+			if !ok {
+				t.Fatalf("got error %v, want a TabIndentationError", err)
+			}
+			if m := tabErr.Mark; m.Line != tt.line || m.Column != tt.col || m.ByteOffset != tt.byteOffset {
+				t.Errorf("tab at %d:%d, byte %d, want %d:%d, byte %d",
+					m.Line, m.Column, m.ByteOffset, tt.line, tt.col, tt.byteOffset)
+			}
+		})
+	}
+
+	allowed := []struct {
+		name string
+		src  string
+	}{
+		{"double quoted", "a: \"x\ty\"\n"},
+		{"quoted continuation", "a: 'x\n\ty'\n"},
+		{"literal content", "a: |\n  \tx\nb: 1\n"},
+		{"folded content", "a: >\n  x\ty\n"},
+		{"inside a comment", "a: 1 # x\ty\n"},
+		{"before a comment", "a: 1\t# c\n"},
+		{"indenting a comment", "[1,\n\t# c\n 2]\n"},
+	}
+	for _, tt := range allowed {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := parseErr(t, tt.src, WithRejectTabs()); err != nil {
+				t.Errorf("got error %v", err)
+			}
+		})
+	}
+}
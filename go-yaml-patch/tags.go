@@ -0,0 +1,113 @@
+package yaml
+
+import "strings"
+
+// defaultTagDirectives lists the tag handles every document starts with
+var defaultTagDirectives = []TagDirective{
+	{Handle: "!", Prefix: "!"},
+	{Handle: "!!", Prefix: "tag:yaml.org,2002:"},
+}
+
+// minTokenRoom is the free space reserveTokens keeps in the token queue,
+// beyond a slot for each indentation level the scanner may close at once
+const minTokenRoom = 16
+
+// reserveTokens makes room in the underlying parser's token queue for the
+// tokens it may scan while producing the next event. The parser compacts
+// the queue when it fills, which drops the tokens it has consumed, and
+// tagSource needs the tag token of the event to still be there.
+func (p *Parser) reserveTokens() {
+	tokens, head := p.parser.tokens, p.parser.tokens_head
+	room := len(p.parser.indents) + minTokenRoom
+	if cap(tokens)-len(tokens) >= room {
+		return
+	}
+	queue := len(tokens) - head
+	if cap(tokens) < queue+room {
+		grown := make([]yaml_token_t, queue, 2*(queue+room))
+		copy(grown, tokens[head:])
+		tokens = grown
+	} else {
+		copy(tokens, tokens[head:])
+		tokens = tokens[:queue]
+	}
+	p.parser.tokens, p.parser.tokens_head = tokens, 0
+}
+
+// tagSource returns the tag of the given event as it is written in the
+// source, from the tag token the underlying parser consumed from index
+// first of its token queue on. Parsers reading UTF-8 from memory slice it
+// out of the input; others rebuild it from the token's handle and suffix,
+// in which percent-escapes are already decoded. If the token is not
+// found, the resolved tag is abbreviated instead.
+func (p *Parser) tagSource(event *Event, first int) []byte {
+	for i := p.parser.tokens_head - 1; i >= first; i-- {
+		token := &p.parser.tokens[i]
+		if token.typ != yaml_TAG_TOKEN {
+			continue
+		}
+		if p.input != nil && p.parser.encoding == yaml_UTF8_ENCODING && event.StartMark.ByteOffset >= 0 {
+			start := event.StartMark.ByteOffset
+			for j := event.StartMark.Index; j < int(token.start_mark.index) && start < len(p.input); j++ {
+				start += p.charWidth(start)
+			}
+			end := start
+			for j := token.start_mark.index; j < token.end_mark.index && end < len(p.input); j++ {
+				end += p.charWidth(end)
+			}
+			return p.input[start:end:end]
+		}
+		handle, suffix := string(token.value), string(token.suffix)
+		switch {
+		case handle == "" && suffix == "!":
+			return []byte(suffix)
+		case handle == "":
+			return []byte("!<" + suffix + ">")
+		default:
+			return []byte(handle + suffix)
+		}
+	}
+	return []byte(p.abbreviateTag(event.tag))
+}
+
+// abbreviateTag abbreviates a resolved tag using the longest matching prefix
+// among the current document's %TAG directives and the default handles.
+// The non-specific "!" tag is returned as is, and tags that no handle
+// covers are written in verbatim "!<...>" form.
+func (p *Parser) abbreviateTag(tag string) string {
+	if tag == "!" {
+		return tag
+	}
+	var best *TagDirective
+	for _, directives := range [][]TagDirective{p.tagDirectives, defaultTagDirectives} {
+		for i := range directives {
+			d := &directives[i]
+			if len(tag) > len(d.Prefix) && strings.HasPrefix(tag, d.Prefix) &&
+				(best == nil || len(d.Prefix) > len(best.Prefix)) {
+				best = d
+			}
+		}
+	}
+	if best == nil {
+		return "!<" + tag + ">"
+	}
+	return best.Handle + tag[len(best.Prefix):]
+}
+
+// fullTag returns the tag of the event in full, such as
+// "tag:yaml.org,2002:str". For events read by a parser whose Tag has not
+// been changed, that is the tag as the parser resolved it. Otherwise Tag
+// is used, with the default "!!" handle and the verbatim "!<...>" form
+// expanded.
+func (e *Event) fullTag() string {
+	switch tag := e.Tag; {
+	case e.tag != "" && tag == e.ShortTag:
+		return e.tag
+	case strings.HasPrefix(tag, "!!"):
+		return defaultTagDirectives[1].Prefix + tag[2:]
+	case strings.HasPrefix(tag, "!<") && strings.HasSuffix(tag, ">"):
+		return tag[2 : len(tag)-1]
+	default:
+		return tag
+	}
+}
@@ -0,0 +1,105 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+)
+
+// firstTagged returns the first event with a tag
+func firstTagged(t testing.TB, events []*Event) *Event {
+	t.Helper()
+	for _, event := range events {
+		if event.Tag != "" {
+			return event
+		}
+	}
+	t.Fatal("no tagged event")
+	return nil
+}
+
+func TestTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		short    string // ShortTag, and Tag by default
+		resolved string // Tag with ResolveTags
+	}{
+		{"secondary handle", "!!str 1\n", "!!str", "tag:yaml.org,2002:str"},
+		{"primary handle", "!local x\n", "!local", "!local"},
+		{"non-specific", "! x\n", "!", "!"},
+		{"verbatim", "!<tag:yaml.org,2002:str> x\n", "!<tag:yaml.org,2002:str>", "tag:yaml.org,2002:str"},
+		{"named handle", "%TAG !e! tag:example.com,2000:\n--- !e!foo bar\n", "!e!foo", "tag:example.com,2000:foo"},
+		{"escaped suffix", "%TAG !e! tag:example.com,2000:app/\n--- !e!a%21 bar\n", "!e!a%21", "tag:example.com,2000:app/a!"},
+		{"redefined primary", "%TAG ! tag:example.com,2000:\n--- !foo bar\n", "!foo", "tag:example.com,2000:foo"},
+		{"after anchor", "&a !!int 3\n", "!!int", "tag:yaml.org,2002:int"},
+		{"mapping value", "key: !!float 1\n", "!!float", "tag:yaml.org,2002:float"},
+		{"flow mapping", "!!map {a: 1}\n", "!!map", "tag:yaml.org,2002:map"},
+		{"block sequence", "--- !!seq\n- a\n", "!!seq", "tag:yaml.org,2002:seq"},
+		{"in flow sequence", "[a, !!str b]\n", "!!str", "tag:yaml.org,2002:str"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := firstTagged(t, parseEvents(t, tt.src))
+			if event.Tag != tt.short || event.ShortTag != tt.short {
+				t.Errorf("Tag %q and ShortTag %q, want both %q", event.Tag, event.ShortTag, tt.short)
+			}
+			resolved := firstTagged(t, parseEvents(t, tt.src, WithResolveTags()))
+			if resolved.Tag != tt.resolved || resolved.ShortTag != tt.short {
+				t.Errorf("with ResolveTags, Tag %q and ShortTag %q, want %q and %q",
+					resolved.Tag, resolved.ShortTag, tt.resolved, tt.short)
+			}
+			if event.ResolvedTag() != resolved.ResolvedTag() {
+				t.Errorf("ResolvedTag is %q, and %q with ResolveTags", event.ResolvedTag(), resolved.ResolvedTag())
+			}
+		})
+	}
+}
+
+func TestTagsReader(t *testing.T) {
+	p, err := NewParser(strings.NewReader("%TAG !e! tag:example.com,2000:\n--- !e!foo bar\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	for {
+		event, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			t.Fatal("no tagged event")
+		}
+		if event.Tag != "" {
+			if event.Tag != "!e!foo" || event.ShortTag != "!e!foo" {
+				t.Errorf("Tag %q and ShortTag %q, want both %q", event.Tag, event.ShortTag, "!e!foo")
+			}
+			return
+		}
+	}
+}
+
+// TestTagsManyEvents checks that the tags of a long stream are all found,
+// past the points where the token queue of the underlying parser fills up
+func TestTagsManyEvents(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("%TAG !e! tag:example.com,2000:\n---\n")
+	for i := 0; i < 500; i++ {
+		b.WriteString("- {k: !e!v x, n: [!!int 1]}\n")
+	}
+	for _, event := range parseEvents(t, b.String()) {
+		if event.Tag == "" {
+			continue
+		}
+		if event.Tag != "!e!v" && event.Tag != "!!int" {
+			t.Fatalf("tag at line %d is %q", event.StartMark.Line+1, event.Tag)
+		}
+	}
+}
+
+func TestEmitShortTags(t *testing.T) {
+	out := emitEvents(t, parseEvents(t, "%TAG !e! tag:example.com,2000:\n--- !e!foo {a: !!int 1}\n"), EmitterOptions{})
+	events := parseEvents(t, out, WithResolveTags())
+	if tag := firstTagged(t, events).Tag; tag != "tag:example.com,2000:foo" {
+		t.Errorf("emitted %q, with the mapping tagged %q", out, tag)
+	}
+}
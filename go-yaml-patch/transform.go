@@ -0,0 +1,60 @@
+package yaml
+
+import "regexp"
+
+// EventTransform is one pass of an event pipeline run by Parser.Pipe. It
+// returns the event to pass on, which may be in itself or a replacement,
+// and false to drop the event from the stream instead.
+//
+// As with Filter, a transform that drops or adds structural events must
+// keep the stream nesting correctly.
+type EventTransform func(in *Event) (*Event, bool, error)
+
+// ReplaceScalars returns a transform that rewrites the value of every
+// scalar, keys included, replacing matches of re with repl as
+// Regexp.ReplaceAllString does. The event is copied before it is changed.
+func ReplaceScalars(re *regexp.Regexp, repl string) EventTransform {
+	return func(in *Event) (*Event, bool, error) {
+		if in.Type != EventScalar {
+			return in, true, nil
+		}
+		value := re.ReplaceAllString(in.Value, repl)
+		if value == in.Value {
+			return in, true, nil
+		}
+		out := *in
+		out.Value = value
+		return &out, true, nil
+	}
+}
+
+// RedactKeys returns a transform that replaces the value of every scalar
+// mapping value whose key is one of the given keys with "****", keeping its
+// style and marks. Keys are compared with the key scalar's value, and
+// values that are collections or aliases are left alone.
+//
+// The transform relies on the InMapping and IsKey fields the parser sets,
+// and remembers the last key it saw, so it must see every content event of
+// the stream and not be shared between pipelines.
+func RedactKeys(keys ...string) EventTransform {
+	redact := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		redact[key] = true
+	}
+
+	// The value of a key is always the next content event after it
+	redactValue := false
+	return func(in *Event) (*Event, bool, error) {
+		if !in.Type.IsContent() {
+			return in, true, nil
+		}
+		out := in
+		if redactValue && in.InMapping && !in.IsKey && in.Type == EventScalar {
+			redacted := *in
+			redacted.Value = "****"
+			out = &redacted
+		}
+		redactValue = in.IsKey && in.Type == EventScalar && redact[in.Value]
+		return out, true, nil
+	}
+}
@@ -0,0 +1,66 @@
+package yaml
+
+import "testing"
+
+// transformValues runs the events through transform and returns the values
+// of the scalars that are not keys, by path
+func transformValues(t *testing.T, events []*Event, transform EventTransform) map[string]string {
+	t.Helper()
+	values := make(map[string]string)
+	for _, event := range events {
+		out, keep, err := transform(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keep && out.Type == EventScalar && !out.IsKey {
+			values[out.Path] = out.Value
+		}
+	}
+	return values
+}
+
+func TestRedactKeys(t *testing.T) {
+	const src = `user: bob
+password: 'hunter2'
+nested:
+  token: abc
+  list: [password, token]
+secret: {password: x, other: y}
+apiKey: [1]
+a: &x 2
+token: *x
+`
+	events := parseEvents(t, src)
+	values := transformValues(t, events, RedactKeys("password", "token", "apiKey"))
+	want := map[string]string{
+		"/user":            "bob",
+		"/password":        "****",
+		"/nested/token":    "****",
+		"/nested/list/0":   "password",
+		"/nested/list/1":   "token",
+		"/secret/password": "****",
+		"/secret/other":    "y",
+		"/apiKey/0":        "1",
+		"/a":               "2",
+	}
+	for path, value := range want {
+		if values[path] != value {
+			t.Errorf("%s is %q, want %q", path, values[path], value)
+		}
+	}
+	if len(values) != len(want) {
+		t.Errorf("got %d scalar values, want %d", len(values), len(want))
+	}
+
+	// The parsed events are copied, not changed
+	findScalarEvent(t, events, "hunter2")
+
+	// The redacted copy keeps the style and marks of the value
+	redact := RedactKeys("password")
+	for _, event := range parseEvents(t, "password: 'x'\n") {
+		out, _, _ := redact(event)
+		if event.Value == "x" && (out.Value != "****" || out.Style != event.Style || out.StartMark != event.StartMark) {
+			t.Errorf("redacted value is %q with style %v", out.Value, out.Style)
+		}
+	}
+}
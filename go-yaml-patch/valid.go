@@ -0,0 +1,41 @@
+package yaml
+
+import "fmt"
+
+// Valid reports whether the given bytes are a well-formed YAML stream,
+// returning the first ParseError found or nil. No Events are built.
+func Valid(input []byte) error {
+	var parser yaml_parser_t
+	if !yaml_parser_initialize(&parser) {
+		return fmt.Errorf("failed to initialize YAML parser")
+	}
+	defer yaml_parser_delete(&parser)
+	if len(input) == 0 {
+		input = []byte{'\n'}
+	}
+	yaml_parser_set_input_string(&parser, input)
+	return runParser(&parser, nil)
+}
+
+// runParser drives the underlying parser to the end of the stream without
+// building Events, calling visit, if not nil, with the type of each event
+func runParser(parser *yaml_parser_t, visit func(yaml_event_type_t)) error {
+	for {
+		var yamlEvent yaml_event_t
+		if !yaml_parser_parse(parser, &yamlEvent) {
+			if parser.error != yaml_NO_ERROR {
+				return newParseError(parser)
+			}
+			return nil
+		}
+		typ := yamlEvent.typ
+		yaml_event_delete(&yamlEvent)
+
+		if visit != nil {
+			visit(typ)
+		}
+		if typ == yaml_STREAM_END_EVENT {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package yaml
+
+import "testing"
+
+func TestValid(t *testing.T) {
+	valid := []string{
+		"",
+		"# only a comment\n",
+		"a: 1\n",
+		"\ufeffa: [1, {b: c}]\n",
+		"--- a\n--- b\n...\n",
+		"%YAML 1.1\n--- !!str x\n",
+		"a: *undefined\n",
+	}
+	for _, src := range valid {
+		if err := Valid([]byte(src)); err != nil {
+			t.Errorf("%q: %v", src, err)
+		}
+	}
+
+	invalid := []struct {
+		src  string
+		typ  ErrorType
+		line int
+	}{
+		{"a: [1, 2\n", ErrorParser, 2},
+		{"a: 1\n b: 2\n", ErrorScanner, 2},
+		{"a: 'unclosed\n", ErrorScanner, 2},
+	}
+	for _, tt := range invalid {
+		err := Valid([]byte(tt.src))
+		parseErr, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("%q: got error %v, want a ParseError", tt.src, err)
+			continue
+		}
+		if parseErr.Type != tt.typ || parseErr.Line != tt.line {
+			t.Errorf("%q: got %v at line %d, want %v at line %d", tt.src, parseErr.Type, parseErr.Line, tt.typ, tt.line)
+		}
+	}
+
+	// Reader errors locate the bad byte by its offset
+	err := Valid([]byte("a: 1\nb: \xff\n"))
+	if parseErr, ok := err.(*ParseError); !ok || parseErr.Type != ErrorReader || parseErr.Offset != 8 {
+		t.Errorf("got error %v for malformed UTF-8, want a reader error at offset 8", err)
+	}
+}